@@ -14,13 +14,19 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"strconv"
 	"time"
 
+	"github.com/magiconair/properties"
 	"github.com/pingcap/go-ycsb/pkg/client"
+	"github.com/pingcap/go-ycsb/pkg/linearizability"
 	"github.com/pingcap/go-ycsb/pkg/measurement"
 	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/session"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
 	"github.com/spf13/cobra"
 )
 
@@ -55,12 +61,91 @@ func runClientCommandFunc(cmd *cobra.Command, args []string, doTransactions bool
 	}
 	fmt.Println("**********************************************")
 
+	if repeat := globalProps.GetInt(prop.Repeat, prop.RepeatDefault); doTransactions && repeat > 1 {
+		runTrials(dbName, repeat)
+		return
+	}
+
 	c := client.NewClient(globalProps, globalWorkload, dbName)
 	start := time.Now()
 	c.Run(globalContext)
 
 	fmt.Printf("Run finished, takes %s\n", time.Now().Sub(start))
 	measurement.Output()
+	linearizability.Output(os.Stdout)
+}
+
+// runTrials runs the run phase repeat times with a fresh workload and
+// measurement each time, then reports the statistics across the trials.
+func runTrials(dbName string, repeat int) {
+	coolDown := globalProps.GetParsedDuration(prop.RepeatCoolDown, 0)
+	reload := globalProps.GetBool(prop.RepeatReload, false)
+
+	var trials [][]ycsb.OpResult
+	for i := 1; i <= repeat; i++ {
+		if i > 1 {
+			if !sleepContext(globalContext, coolDown) {
+				break
+			}
+			if reload {
+				runReload(dbName)
+			}
+
+			globalWorkload.Close()
+			globalWorkload = createWorkload(globalProps)
+			measurement.InitMeasure(globalProps)
+			linearizability.Init(globalProps)
+			session.Init(globalProps)
+		}
+
+		fmt.Printf("***************** trial %d/%d *****************\n", i, repeat)
+		c := client.NewClient(globalProps, globalWorkload, dbName)
+		start := time.Now()
+		c.Run(globalContext)
+		if globalContext.Err() != nil {
+			break
+		}
+
+		fmt.Printf("Trial %d finished, takes %s\n", i, time.Now().Sub(start))
+		measurement.Output()
+		linearizability.Output(os.Stdout)
+		trials = append(trials, measurement.Results())
+	}
+
+	fmt.Printf("***************** %d trials *****************\n", len(trials))
+	measurement.OutputTrials(os.Stdout, trials)
+}
+
+// runReload runs the load phase again between two trials.
+func runReload(dbName string) {
+	loadProps := properties.NewProperties()
+	loadProps.Merge(globalProps)
+	loadProps.Set(prop.DoTransactions, "false")
+	loadProps.Set(prop.Command, "load")
+
+	workload := createWorkload(loadProps)
+	defer workload.Close()
+	measurement.InitMeasure(loadProps)
+	linearizability.Init(loadProps)
+	session.Init(loadProps)
+
+	fmt.Println("Reloading data")
+	c := client.NewClient(loadProps, workload, dbName)
+	start := time.Now()
+	c.Run(globalContext)
+	fmt.Printf("Reload finished, takes %s\n", time.Now().Sub(start))
+}
+
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
 }
 
 func runLoadCommandFunc(cmd *cobra.Command, args []string) {
@@ -32,8 +32,10 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/pingcap/go-ycsb/pkg/client"
+	"github.com/pingcap/go-ycsb/pkg/linearizability"
 	"github.com/pingcap/go-ycsb/pkg/measurement"
 	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/session"
 	"github.com/pingcap/go-ycsb/pkg/util"
 	_ "github.com/pingcap/go-ycsb/pkg/workload"
 	"github.com/pingcap/go-ycsb/pkg/ycsb"
@@ -94,19 +96,16 @@ func initialGlobal(dbName string, onProperties func()) {
 	}()
 
 	measurement.InitMeasure(globalProps)
+	linearizability.Init(globalProps)
+	session.Init(globalProps)
 
 	if len(tableName) == 0 {
 		tableName = globalProps.GetString(prop.TableName, prop.TableNameDefault)
 	}
 
-	workloadName := globalProps.GetString(prop.Workload, "core")
-	workloadCreator := ycsb.GetWorkloadCreator(workloadName)
+	globalWorkload = createWorkload(globalProps)
 
 	var err error
-	if globalWorkload, err = workloadCreator.Create(globalProps); err != nil {
-		util.Fatalf("create workload %s failed %v", workloadName, err)
-	}
-
 	if onProperties == nil {
 		dbCreator := ycsb.GetDBCreator(dbName)
 		if dbCreator == nil {
@@ -115,8 +114,22 @@ func initialGlobal(dbName string, onProperties func()) {
 		if globalDB, err = dbCreator.Create(globalProps); err != nil {
 			util.Fatalf("create db %s failed %v", dbName, err)
 		}
-		globalDB = client.DbWrapper{globalDB}
+		globalDB = client.DbWrapper{DB: globalDB}
+	}
+}
+
+func createWorkload(p *properties.Properties) ycsb.Workload {
+	workloadName := p.GetString(prop.Workload, "core")
+	workloadCreator := ycsb.GetWorkloadCreator(workloadName)
+	if workloadCreator == nil {
+		util.Fatalf("workload %s is not registered", workloadName)
+	}
+
+	workload, err := workloadCreator.Create(p)
+	if err != nil {
+		util.Fatalf("create workload %s failed %v", workloadName, err)
 	}
+	return workload
 }
 
 func main() {
@@ -157,6 +170,7 @@ func main() {
 		newShellCommand(),
 		newLoadCommand(),
 		newRunCommand(),
+		newVerifyCommand(),
 	)
 
 	cobra.EnablePrefixMatching = true
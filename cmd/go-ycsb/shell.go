@@ -40,12 +40,20 @@ func newShellCommand() *cobra.Command {
 	return m
 }
 
-var shellContext context.Context
+var (
+	shellContext context.Context
+	shellSchema  *util.Schema
+)
 
 func runShellCommandFunc(cmd *cobra.Command, args []string) {
 	dbName := args[0]
 	initialGlobal(dbName, nil)
 
+	var err error
+	if shellSchema, err = util.NewSchema(globalProps); err != nil {
+		util.Fatalf("bad schema: %v", err)
+	}
+
 	shellContext = globalWorkload.InitThread(globalContext, 0, 1)
 	shellContext = globalDB.InitThread(shellContext, 0, 1)
 
@@ -129,8 +137,14 @@ func runShellReadCommand(cmd *cobra.Command, args []string) {
 	}
 
 	fmt.Printf("Read %s ok\n", key)
-	for key, value := range row {
-		fmt.Printf("%s=%q\n", key, value)
+	printRow(row)
+}
+
+// printRow prints the fields of a row in order, with the values formatted
+// by their type in the schema.
+func printRow(row map[string][]byte) {
+	for _, pair := range util.NewFieldPairs(row) {
+		fmt.Printf("%s=%s\n", pair.Field, shellSchema.Format(pair.Field, pair.Value))
 	}
 }
 
@@ -157,9 +171,7 @@ func runShellScanCommand(cmd *cobra.Command, args []string) {
 	fmt.Println("--------------------------------")
 	for i, row := range rows {
 		fmt.Printf("Record %d\n", i+1)
-		for key, value := range row {
-			fmt.Printf("%s=%q\n", key, value)
-		}
+		printRow(row)
 	}
 	fmt.Println("--------------------------------")
 }
@@ -0,0 +1,123 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+	"github.com/spf13/cobra"
+)
+
+func runVerifyCommandFunc(cmd *cobra.Command, args []string) {
+	dbName := args[0]
+
+	initialGlobal(dbName, func() {
+		globalProps.Set(prop.DoTransactions, "false")
+		globalProps.Set(prop.Command, "verify")
+
+		if cmd.Flags().Changed("threads") {
+			globalProps.Set(prop.ThreadCount, strconv.Itoa(threadsArg))
+		}
+	})
+
+	workloadName := globalProps.GetString(prop.Workload, "core")
+	verifier, ok := globalWorkload.(ycsb.VerifyWorkload)
+	if !ok {
+		util.Fatalf("workload %s can't be verified", workloadName)
+	}
+
+	dbCreator := ycsb.GetDBCreator(dbName)
+	if dbCreator == nil {
+		util.Fatalf("%s is not registered", dbName)
+	}
+	// The databases are not wrapped, the verification reads are not
+	// benchmarked and the optional interfaces stay visible. The workload strips
+	// the headers of the session check itself.
+	dbs := make([]ycsb.DB, globalProps.GetInt(prop.ThreadCount, 1))
+	for i := range dbs {
+		db, err := dbCreator.Create(globalProps)
+		if err != nil {
+			util.Fatalf("create db %s failed %v", dbName, err)
+		}
+		dbs[i] = db
+	}
+
+	start := time.Now()
+	report, err := verifier.Verify(globalContext, dbs)
+	for _, db := range dbs {
+		db.Close()
+	}
+	if err != nil {
+		util.Fatalf("verify failed: %v", err)
+	}
+	fmt.Printf("Verify finished, takes %s\n", time.Now().Sub(start))
+
+	printVerifyReport(report)
+	if report.Missing.Count > 0 || report.Extra.Count > 0 || report.Corrupted.Count > 0 {
+		// exit with an error for the scripts running it after a chaos test.
+		os.Exit(1)
+	}
+}
+
+func printVerifyReport(report *ycsb.VerifyReport) {
+	how := "read"
+	if report.Scanned {
+		how = "scanned"
+	}
+	fmt.Printf("Checked %d records, %s\n", report.Checked, how)
+	issues := []struct {
+		name string
+		v    ycsb.VerifyIssues
+	}{
+		{"Missing", report.Missing},
+		{"Extra", report.Extra},
+		{"Corrupted", report.Corrupted},
+	}
+	for _, issue := range issues {
+		if issue.name == "Extra" && !report.Scanned {
+			fmt.Println("Extra: not checked without a scan")
+			continue
+		}
+		fmt.Printf("%s: %d", issue.name, issue.v.Count)
+		if len(issue.v.Samples) > 0 {
+			samples := make([]string, len(issue.v.Samples))
+			for i, key := range issue.v.Samples {
+				samples[i] = strconv.Quote(key)
+			}
+			fmt.Printf(", e.g. %s", strings.Join(samples, ", "))
+		}
+		fmt.Println()
+	}
+}
+
+func newVerifyCommand() *cobra.Command {
+	m := &cobra.Command{
+		Use:   "verify db",
+		Short: "Verify the records of the workload in the database",
+		Args:  cobra.MinimumNArgs(1),
+		Run:   runVerifyCommandFunc,
+	}
+
+	m.Flags().StringSliceVarP(&propertyFiles, "property_file", "P", nil, "Spefify a property file")
+	m.Flags().StringArrayVarP(&propertyValues, "prop", "p", nil, "Specify a property value with name=value")
+	m.Flags().IntVar(&threadsArg, "threads", 1, "Verify using n threads - can also be specified as the \"threadcount\" property")
+	return m
+}
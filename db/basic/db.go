@@ -36,6 +36,7 @@ import (
 	"database/sql"
 	"fmt"
 	"math/rand"
+	"sync/atomic"
 	"time"
 
 	"github.com/magiconair/properties"
@@ -65,6 +66,8 @@ type basicDB struct {
 	verbose        bool
 	randomizeDelay bool
 	toDelay        int64
+	// lastLease is the ID of the last lease granted.
+	lastLease int64
 }
 
 func (db *basicDB) delay(ctx context.Context, state *basicState) {
@@ -116,40 +119,27 @@ func (db *basicDB) Read(ctx context.Context, table string, key string, fields []
 		return nil, nil
 	}
 
-	buf := state.buf
-	s := fmt.Sprintf("READ %s %s [ ", table, key)
-	buf.WriteString(s)
-
-	if len(fields) > 0 {
-		for _, f := range fields {
-			buf.WriteString(f)
-			buf.WriteByte(' ')
-		}
-	} else {
-		buf.WriteString("<all fields> ")
-	}
-	buf.WriteByte(']')
-	fmt.Println(buf.String())
-	buf.Reset()
+	readRecord(state.buf, fmt.Sprintf("READ %s %s", table, key), fields)
 	return nil, nil
 }
 
 func (db *basicDB) BatchRead(ctx context.Context, table string, keys []string, fields []string) ([]map[string][]byte, error) {
-	panic("The basicDB has not implemented the batch operation")
-}
-
-func (db *basicDB) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
 	state := ctx.Value(stateKey).(*basicState)
 
 	db.delay(ctx, state)
 
 	if !db.verbose {
-		return nil, nil
+		return make([]map[string][]byte, len(keys)), nil
 	}
+	for _, key := range keys {
+		readRecord(state.buf, fmt.Sprintf("READ %s %s", table, key), fields)
+	}
+	return make([]map[string][]byte, len(keys)), nil
+}
 
-	buf := state.buf
-	s := fmt.Sprintf("SCAN %s %s %d [ ", table, startKey, count)
-	buf.WriteString(s)
+func readRecord(buf *bytes.Buffer, op string, fields []string) {
+	buf.WriteString(op)
+	buf.WriteString(" [ ")
 
 	if len(fields) > 0 {
 		for _, f := range fields {
@@ -162,37 +152,60 @@ func (db *basicDB) Scan(ctx context.Context, table string, startKey string, coun
 	buf.WriteByte(']')
 	fmt.Println(buf.String())
 	buf.Reset()
+}
+
+func (db *basicDB) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
+	state := ctx.Value(stateKey).(*basicState)
+
+	db.delay(ctx, state)
+
+	if !db.verbose {
+		return nil, nil
+	}
+
+	readRecord(state.buf, fmt.Sprintf("SCAN %s %s %d", table, startKey, count), fields)
 	return nil, nil
 }
 
-func (db *basicDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+func (db *basicDB) BatchScan(ctx context.Context, table string, startKeys []string, counts []int, fields []string) ([][]map[string][]byte, error) {
 	state := ctx.Value(stateKey).(*basicState)
 
 	db.delay(ctx, state)
 
 	if !db.verbose {
-		return nil
+		return make([][]map[string][]byte, len(startKeys)), nil
 	}
+	for i, key := range startKeys {
+		readRecord(state.buf, fmt.Sprintf("SCAN %s %s %d", table, key, counts[i]), fields)
+	}
+	return make([][]map[string][]byte, len(startKeys)), nil
+}
 
-	buf := state.buf
-	s := fmt.Sprintf("UPDATE %s %s [ ", table, key)
-	buf.WriteString(s)
+func (db *basicDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	state := ctx.Value(stateKey).(*basicState)
 
-	for key, value := range values {
-		buf.WriteString(key)
-		buf.WriteByte('=')
-		buf.Write(value)
-		buf.WriteByte(' ')
+	db.delay(ctx, state)
+
+	if !db.verbose {
+		return nil
 	}
 
-	buf.WriteByte(']')
-	fmt.Println(buf.String())
-	buf.Reset()
+	writeRecord(state.buf, "UPDATE", table, key, values)
 	return nil
 }
 
 func (db *basicDB) BatchUpdate(ctx context.Context, table string, keys []string, values []map[string][]byte) error {
-	panic("The basicDB has not implemented the batch operation")
+	state := ctx.Value(stateKey).(*basicState)
+
+	db.delay(ctx, state)
+
+	if !db.verbose {
+		return nil
+	}
+	for i, key := range keys {
+		writeRecord(state.buf, "UPDATE", table, key, values[i])
+	}
+	return nil
 }
 
 func (db *basicDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
@@ -204,8 +217,7 @@ func (db *basicDB) Insert(ctx context.Context, table string, key string, values
 		return nil
 	}
 
-	buf := state.buf
-	insertRecord(buf, table, key, values)
+	writeRecord(state.buf, "INSERT", table, key, values)
 	return nil
 }
 
@@ -217,15 +229,14 @@ func (db *basicDB) BatchInsert(ctx context.Context, table string, keys []string,
 	if !db.verbose {
 		return nil
 	}
-	buf := state.buf
 	for i, key := range keys {
-		insertRecord(buf, table, key, values[i])
+		writeRecord(state.buf, "INSERT", table, key, values[i])
 	}
 	return nil
 }
 
-func insertRecord(buf *bytes.Buffer, table string, key string, values map[string][]byte) {
-	s := fmt.Sprintf("INSERT %s %s [ ", table, key)
+func writeRecord(buf *bytes.Buffer, op string, table string, key string, values map[string][]byte) {
+	s := fmt.Sprintf("%s %s %s [ ", op, table, key)
 	buf.WriteString(s)
 	for valueKey, value := range values {
 		buf.WriteString(valueKey)
@@ -247,16 +258,117 @@ func (db *basicDB) Delete(ctx context.Context, table string, key string) error {
 	}
 
 	buf := state.buf
+	deleteRecord(buf, table, key)
+	return nil
+}
+
+func (db *basicDB) BatchDelete(ctx context.Context, table string, keys []string) error {
+	state := ctx.Value(stateKey).(*basicState)
+
+	db.delay(ctx, state)
+	if !db.verbose {
+		return nil
+	}
+	buf := state.buf
+	for _, key := range keys {
+		deleteRecord(buf, table, key)
+	}
+	return nil
+}
+
+func deleteRecord(buf *bytes.Buffer, table string, key string) {
 	s := fmt.Sprintf("DELETE %s %s", table, key)
 	buf.WriteString(s)
 
 	fmt.Println(buf.String())
 	buf.Reset()
+}
+
+// ReadVersion implements the CASDB ReadVersion interface, all the records
+// are at version 0.
+func (db *basicDB) ReadVersion(ctx context.Context, table string, key string, fields []string) (map[string][]byte, int64, error) {
+	state := ctx.Value(stateKey).(*basicState)
+
+	db.delay(ctx, state)
+
+	if db.verbose {
+		readRecord(state.buf, fmt.Sprintf("READ_VERSION %s %s", table, key), fields)
+	}
+	return nil, 0, nil
+}
+
+// CompareAndSwap implements the CASDB CompareAndSwap interface, a swap
+// always succeeds.
+func (db *basicDB) CompareAndSwap(ctx context.Context, table string, key string, version int64, values map[string][]byte) (bool, error) {
+	state := ctx.Value(stateKey).(*basicState)
+
+	db.delay(ctx, state)
+
+	if db.verbose {
+		writeRecord(state.buf, fmt.Sprintf("CAS %d", version), table, key, values)
+	}
+	return true, nil
+}
+
+// GrantLease implements the LeaseDB GrantLease interface, the leases are not
+// tracked.
+func (db *basicDB) GrantLease(ctx context.Context, ttl time.Duration) (int64, time.Duration, error) {
+	lease := atomic.AddInt64(&db.lastLease, 1)
+	db.print(ctx, fmt.Sprintf("LEASE_GRANT %d %s", lease, ttl))
+	return lease, ttl, nil
+}
+
+// PutWithLease implements the LeaseDB PutWithLease interface.
+func (db *basicDB) PutWithLease(ctx context.Context, table string, key string, values map[string][]byte, lease int64) error {
+	state := ctx.Value(stateKey).(*basicState)
+
+	db.delay(ctx, state)
+
+	if db.verbose {
+		writeRecord(state.buf, fmt.Sprintf("LEASE_PUT %d", lease), table, key, values)
+	}
 	return nil
 }
 
-func (db *basicDB) BatchDelete(ctx context.Context, table string, keys []string) error {
-	panic("The basicDB has not implemented the batch operation")
+// KeepAliveLease implements the LeaseDB KeepAliveLease interface.
+func (db *basicDB) KeepAliveLease(ctx context.Context, lease int64) error {
+	db.print(ctx, fmt.Sprintf("LEASE_KEEPALIVE %d", lease))
+	return nil
+}
+
+// RevokeLease implements the LeaseDB RevokeLease interface.
+func (db *basicDB) RevokeLease(ctx context.Context, lease int64) error {
+	db.print(ctx, fmt.Sprintf("LEASE_REVOKE %d", lease))
+	return nil
+}
+
+func (db *basicDB) print(ctx context.Context, s string) {
+	state := ctx.Value(stateKey).(*basicState)
+
+	db.delay(ctx, state)
+	if db.verbose {
+		fmt.Println(s)
+	}
+}
+
+func (db *basicDB) Begin(ctx context.Context) (ycsb.Txn, error) {
+	db.print(ctx, "BEGIN")
+	return basicTxn{db}, nil
+}
+
+// basicTxn prints out the operations of a transaction, which never conflicts.
+type basicTxn struct {
+	*basicDB
+}
+
+func (t basicTxn) Commit(ctx context.Context) error {
+	t.print(ctx, "COMMIT")
+	return nil
+}
+
+func (t basicTxn) Rollback(ctx context.Context) error {
+	t.print(ctx, "ROLLBACK")
+	return nil
 }
 
 type basicDBCreator struct{}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -12,6 +13,7 @@ import (
 	clientv3 "go.etcd.io/etcd/client/v3"
 
 	"github.com/magiconair/properties"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	"go.etcd.io/etcd/client/pkg/v3/transport"
 
 	"github.com/pingcap/go-ycsb/pkg/ycsb"
@@ -102,7 +104,7 @@ func (db *etcdDB) Read(ctx context.Context, table string, key string, _ []string
 	}
 
 	if value.Count == 0 {
-		return nil, fmt.Errorf("could not find value for key [%s]", rkey)
+		return nil, fmt.Errorf("%w: could not find value for key [%s]", ycsb.ErrNotFound, rkey)
 	}
 
 	var r map[string][]byte
@@ -136,6 +138,27 @@ func (db *etcdDB) Scan(ctx context.Context, table string, startKey string, count
 	return res, nil
 }
 
+// ScanKeys implements the KeyScanDB ScanKeys interface.
+func (db *etcdDB) ScanKeys(ctx context.Context, table string, startKey string, count int, _ []string) ([]string, []map[string][]byte, error) {
+	// ';' follows ':', so the range ends at the end of the table.
+	resp, err := db.client.Get(ctx, getRowKey(table, startKey), clientv3.WithRange(table+";"), clientv3.WithLimit(int64(count)))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keys := make([]string, 0, len(resp.Kvs))
+	res := make([]map[string][]byte, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var r map[string][]byte
+		if err = json.NewDecoder(bytes.NewReader(kv.Value)).Decode(&r); err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, string(kv.Key[len(table)+1:]))
+		res = append(res, r)
+	}
+	return keys, res, nil
+}
+
 func (db *etcdDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
 	rkey := getRowKey(table, key)
 	data, err := json.Marshal(values)
@@ -150,6 +173,138 @@ func (db *etcdDB) Update(ctx context.Context, table string, key string, values m
 	return nil
 }
 
+// ReadVersion implements the CASDB ReadVersion interface, the version is the
+// mod revision of the key.
+func (db *etcdDB) ReadVersion(ctx context.Context, table string, key string, _ []string) (map[string][]byte, int64, error) {
+	rkey := getRowKey(table, key)
+	value, err := db.client.Get(ctx, rkey)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if value.Count == 0 {
+		return nil, 0, fmt.Errorf("%w: could not find value for key [%s]", ycsb.ErrNotFound, rkey)
+	}
+
+	var r map[string][]byte
+	err = json.NewDecoder(bytes.NewReader(value.Kvs[0].Value)).Decode(&r)
+	if err != nil {
+		return nil, 0, err
+	}
+	return r, value.Kvs[0].ModRevision, nil
+}
+
+// CompareAndSwap implements the CASDB CompareAndSwap interface with a
+// transaction comparing the mod revision of the key.
+func (db *etcdDB) CompareAndSwap(ctx context.Context, table string, key string, version int64, values map[string][]byte) (bool, error) {
+	rkey := getRowKey(table, key)
+	data, err := json.Marshal(values)
+	if err != nil {
+		return false, err
+	}
+	resp, err := db.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.ModRevision(rkey), "=", version)).
+		Then(clientv3.OpPut(rkey, string(data))).
+		Commit()
+	if err != nil {
+		return false, err
+	}
+	return resp.Succeeded, nil
+}
+
+// leaseError wraps the error of a lease not found into ycsb.ErrLeaseNotFound.
+func leaseError(err error) error {
+	if errors.Is(err, rpctypes.ErrLeaseNotFound) {
+		return fmt.Errorf("%w: %v", ycsb.ErrLeaseNotFound, err)
+	}
+	return err
+}
+
+// GrantLease implements the LeaseDB GrantLease interface, the TTL is rounded
+// up to seconds.
+func (db *etcdDB) GrantLease(ctx context.Context, ttl time.Duration) (int64, time.Duration, error) {
+	seconds := int64((ttl + time.Second - 1) / time.Second)
+	resp, err := db.client.Grant(ctx, seconds)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int64(resp.ID), time.Duration(resp.TTL) * time.Second, nil
+}
+
+// PutWithLease implements the LeaseDB PutWithLease interface.
+func (db *etcdDB) PutWithLease(ctx context.Context, table string, key string, values map[string][]byte, lease int64) error {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	_, err = db.client.Put(ctx, getRowKey(table, key), string(data), clientv3.WithLease(clientv3.LeaseID(lease)))
+	return leaseError(err)
+}
+
+// KeepAliveLease implements the LeaseDB KeepAliveLease interface.
+func (db *etcdDB) KeepAliveLease(ctx context.Context, lease int64) error {
+	_, err := db.client.KeepAliveOnce(ctx, clientv3.LeaseID(lease))
+	return leaseError(err)
+}
+
+// RevokeLease implements the LeaseDB RevokeLease interface.
+func (db *etcdDB) RevokeLease(ctx context.Context, lease int64) error {
+	_, err := db.client.Revoke(ctx, clientv3.LeaseID(lease))
+	return leaseError(err)
+}
+
+// Watch implements the WatchDB Watch interface.
+func (db *etcdDB) Watch(ctx context.Context, table string) (<-chan ycsb.WatchEvent, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	wch := db.client.Watch(ctx, getRowKey(table, ""), clientv3.WithRange(table+";"), clientv3.WithCreatedNotify())
+	// the first response tells the watch is created.
+	resp, ok := <-wch
+	if !ok {
+		cancel()
+		return nil, fmt.Errorf("watch of table %s closed before it was created", table)
+	}
+	if err := resp.Err(); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	ch := make(chan ycsb.WatchEvent, 128)
+	go func() {
+		defer cancel()
+		defer close(ch)
+
+		send := func(e ycsb.WatchEvent) bool {
+			select {
+			case ch <- e:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		for resp := range wch {
+			if err := resp.Err(); err != nil {
+				send(ycsb.WatchEvent{Err: err})
+				return
+			}
+			for _, ev := range resp.Events {
+				e := ycsb.WatchEvent{Key: string(ev.Kv.Key[len(table)+1:])}
+				if ev.Type == clientv3.EventTypePut {
+					if err := json.NewDecoder(bytes.NewReader(ev.Kv.Value)).Decode(&e.Values); err != nil {
+						e.Err = err
+					}
+				}
+				if !send(e) || e.Err != nil {
+					return
+				}
+			}
+		}
+		if err := ctx.Err(); err == nil {
+			send(ycsb.WatchEvent{Err: fmt.Errorf("watch of table %s closed", table)})
+		}
+	}()
+	return ch, nil
+}
+
 func (db *etcdDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
 	return db.Update(ctx, table, key, values)
 }
@@ -0,0 +1,154 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/magiconair/properties"
+	"go.etcd.io/etcd/server/v3/embed"
+
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// startEtcd starts a single node etcd server and returns a DB connected to it.
+func startEtcd(t *testing.T) *etcdDB {
+	cfg := embed.NewConfig()
+	cfg.Dir = filepath.Join(t.TempDir(), "etcd")
+	cfg.LogLevel = "error"
+	local, _ := url.Parse("http://127.0.0.1:0")
+	cfg.LCUrls, cfg.ACUrls = []url.URL{*local}, []url.URL{*local}
+	cfg.LPUrls, cfg.APUrls = []url.URL{*local}, []url.URL{*local}
+	cfg.InitialCluster = cfg.InitialClusterFromName(cfg.Name)
+
+	e, err := embed.StartEtcd(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(e.Close)
+	select {
+	case <-e.Server.ReadyNotify():
+	case <-time.After(10 * time.Second):
+		t.Fatal("etcd is not ready")
+	}
+
+	p := properties.NewProperties()
+	p.Set(etcdEndpoints, e.Clients[0].Addr().String())
+	db, err := etcdCreator{}.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db.(*etcdDB)
+}
+
+func TestLease(t *testing.T) {
+	db := startEtcd(t)
+	ctx := context.Background()
+	values := map[string][]byte{"field0": []byte("value")}
+	exists := func(key string) bool {
+		_, err := db.Read(ctx, "usertable", key, nil)
+		if err != nil && !errors.Is(err, ycsb.ErrNotFound) {
+			t.Fatal(err)
+		}
+		return err == nil
+	}
+
+	// the TTL is rounded up to seconds.
+	lease, ttl, err := db.GrantLease(ctx, 500*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ttl < time.Second {
+		t.Fatalf("the granted TTL %s is shorter than asked", ttl)
+	}
+	for _, key := range []string{"k0", "k1"} {
+		if err := db.PutWithLease(ctx, "usertable", key, values, lease); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.KeepAliveLease(ctx, lease); err != nil {
+		t.Fatal(err)
+	}
+
+	// a revoked lease deletes its keys and is not found anymore.
+	if err := db.RevokeLease(ctx, lease); err != nil {
+		t.Fatal(err)
+	}
+	if exists("k0") || exists("k1") {
+		t.Fatal("the keys of the revoked lease are still there")
+	}
+	if err := db.KeepAliveLease(ctx, lease); !errors.Is(err, ycsb.ErrLeaseNotFound) {
+		t.Fatalf("expect the revoked lease to be not found, got %v", err)
+	}
+	if err := db.PutWithLease(ctx, "usertable", "k0", values, lease); !errors.Is(err, ycsb.ErrLeaseNotFound) {
+		t.Fatalf("expect no put with the revoked lease, got %v", err)
+	}
+
+	// a lease left alone expires with its keys.
+	lease, ttl, err = db.GrantLease(ctx, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.PutWithLease(ctx, "usertable", "k2", values, lease); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(3 * ttl)
+	for exists("k2") && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if exists("k2") {
+		t.Fatalf("the key of the lease is still there after %s", 3*ttl)
+	}
+	if err := db.KeepAliveLease(ctx, lease); !errors.Is(err, ycsb.ErrLeaseNotFound) {
+		t.Fatalf("expect the expired lease to be not found, got %v", err)
+	}
+}
+
+func TestCompareAndSwap(t *testing.T) {
+	db := startEtcd(t)
+	ctx := context.Background()
+
+	if _, _, err := db.ReadVersion(ctx, "usertable", "k0", nil); !errors.Is(err, ycsb.ErrNotFound) {
+		t.Fatalf("expect the missing record to be not found, got %v", err)
+	}
+
+	if err := db.Insert(ctx, "usertable", "k0", map[string][]byte{"field0": []byte("a")}); err != nil {
+		t.Fatal(err)
+	}
+	values, version, err := db.ReadVersion(ctx, "usertable", "k0", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(values["field0"]) != "a" {
+		t.Fatalf("expect the inserted value, got %q", values)
+	}
+	applied, err := db.CompareAndSwap(ctx, "usertable", "k0", version, map[string][]byte{"field0": []byte("b")})
+	if err != nil || !applied {
+		t.Fatalf("expect the swap of version %d to be applied, got %v", version, err)
+	}
+	// the record has a new version since.
+	applied, err = db.CompareAndSwap(ctx, "usertable", "k0", version, map[string][]byte{"field0": []byte("c")})
+	if err != nil || applied {
+		t.Fatalf("expect the swap of the stale version %d to fail, got %v", version, err)
+	}
+	if values, err = db.Read(ctx, "usertable", "k0", nil); err != nil || string(values["field0"]) != "b" {
+		t.Fatalf("expect the swapped value, got %q, %v", values, err)
+	}
+}
@@ -7,8 +7,8 @@ import (
 	"fmt"
 	"github.com/magiconair/properties"
 	config2 "github.com/pingcap/go-ycsb/config"
+	"github.com/pingcap/go-ycsb/pkg/util"
 	"github.com/pingcap/go-ycsb/pkg/ycsb"
-	"regexp"
 )
 
 const KeyNotFound = "key not found"
@@ -38,13 +38,9 @@ func (c *mpaxosClient) Read(ctx context.Context, table string, key string, field
 		return nil, err
 	}
 	if result == KeyNotFound {
-		return map[string][]byte{"field0": []byte("")}, nil
+		return map[string][]byte{}, nil
 	}
-	valueByte := []byte(result)
-	fieldMap := map[string][]byte{
-		"field0": valueByte,
-	}
-	return fieldMap, nil
+	return util.DecodeFields(result, fields)
 }
 
 func (c *mpaxosClient) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
@@ -56,8 +52,7 @@ func (c *mpaxosClient) Update(ctx context.Context, table string, key string, val
 }
 
 func (c *mpaxosClient) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
-	valBytes := encode(values)
-	val := string(valBytes[:])
+	val := util.EncodeFields(values)
 	result, err := c.client.Put(key, val)
 	if err != nil {
 		return err
@@ -98,31 +93,3 @@ func (r mpaxosCreator) Create(p *properties.Properties) (ycsb.DB, error) {
 func init() {
 	ycsb.RegisterDBCreator("multipaxos", mpaxosCreator{})
 }
-
-func encode(values map[string][]byte) []byte {
-	valBytes := make([]byte, 0)
-	for _, val := range values {
-		//fieldBytes := []byte(field + "=")
-		//valBytes = append(valBytes, fieldBytes...)
-		valBytes = append(valBytes, val...)
-	}
-	return valBytes
-}
-
-func decode(values []byte) map[string][]byte {
-	// Assume the field name is from "field0" to "field9"
-	m := regexp.MustCompile("field[0-9]=")
-	valString := string(values)
-	fields := m.FindAllString(valString, -1)
-	vals := m.Split(valString, -1)
-
-	fieldMap := make(map[string][]byte)
-	for i, val := range vals {
-		// the first val is nil
-		if i == 0 {
-			continue
-		}
-		fieldMap[fields[i-1]] = []byte(val)
-	}
-	return fieldMap
-}
@@ -6,8 +6,8 @@ import (
 	"fmt"
 	"github.com/ailidani/paxi"
 	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/util"
 	"github.com/pingcap/go-ycsb/pkg/ycsb"
-	"regexp"
 )
 
 type paxiHttpClient struct {
@@ -34,9 +34,7 @@ func (c *paxiHttpClient) Read(ctx context.Context, table string, key string, fie
 	if err != nil {
 		return nil, err
 	}
-	valueByte := []byte(result)
-	fieldMap := decode(valueByte)
-	return fieldMap, nil
+	return util.DecodeFields(result, fields)
 }
 
 func (c *paxiHttpClient) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
@@ -48,8 +46,7 @@ func (c *paxiHttpClient) Update(ctx context.Context, table string, key string, v
 }
 
 func (c *paxiHttpClient) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
-	valBytes := encode(values)
-	val := string(valBytes[:])
+	val := util.EncodeFields(values)
 	err := c.client.Put(key, val)
 	if err != nil {
 		return err
@@ -78,31 +75,3 @@ func (r paxiHttpCreator) Create(p *properties.Properties) (ycsb.DB, error) {
 func init() {
 	ycsb.RegisterDBCreator("paxi", paxiHttpCreator{})
 }
-
-func encode(values map[string][]byte) []byte {
-	valBytes := make([]byte, 0)
-	for field, val := range values {
-		fieldBytes := []byte(field + "=")
-		valBytes = append(valBytes, fieldBytes...)
-		valBytes = append(valBytes, val...)
-	}
-	return valBytes
-}
-
-func decode(values []byte) map[string][]byte {
-	// Assume the field name is from "field0" to "field9"
-	m := regexp.MustCompile("field[0-9]=")
-	valString := string(values)
-	fields := m.FindAllString(valString, -1)
-	vals := m.Split(valString, -1)
-
-	fieldMap := make(map[string][]byte)
-	for i, val := range vals {
-		// the first val is nil
-		if i == 0 {
-			continue
-		}
-		fieldMap[fields[i-1]] = []byte(val)
-	}
-	return fieldMap
-}
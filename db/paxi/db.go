@@ -5,8 +5,8 @@ import (
 	"database/sql"
 	"fmt"
 	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/util"
 	"github.com/pingcap/go-ycsb/pkg/ycsb"
-	"regexp"
 )
 
 type paxiClient struct {
@@ -33,9 +33,7 @@ func (c *paxiClient) Read(ctx context.Context, table string, key string, fields
 	if err != nil {
 		return nil, err
 	}
-	valueByte := []byte(result)
-	fieldMap := decode(valueByte)
-	return fieldMap, nil
+	return util.DecodeFields(result, fields)
 }
 
 func (c *paxiClient) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
@@ -47,8 +45,7 @@ func (c *paxiClient) Update(ctx context.Context, table string, key string, value
 }
 
 func (c *paxiClient) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
-	valBytes := encode(values)
-	val := string(valBytes[:])
+	val := util.EncodeFields(values)
 	_, err := c.client.Put(key, val)
 	if err != nil {
 		return err
@@ -76,31 +73,3 @@ func (r paxiCreator) Create(p *properties.Properties) (ycsb.DB, error) {
 func init() {
 	ycsb.RegisterDBCreator("paxi-tcp", paxiCreator{})
 }
-
-func encode(values map[string][]byte) []byte {
-	valBytes := make([]byte, 0)
-	for field, val := range values {
-		fieldBytes := []byte(" " + field + "=")
-		valBytes = append(valBytes, fieldBytes...)
-		valBytes = append(valBytes, val...)
-	}
-	return valBytes
-}
-
-func decode(values []byte) map[string][]byte {
-	// Assume the field name is from "field0" to "field9"
-	m := regexp.MustCompile("field[0-9]=")
-	valString := string(values)
-	fields := m.FindAllString(valString, -1)
-	vals := m.Split(valString, -1)
-
-	fieldMap := make(map[string][]byte)
-	for i, val := range vals {
-		// the first val is nil
-		if i == 0 {
-			continue
-		}
-		fieldMap[fields[i-1]] = []byte(val)
-	}
-	return fieldMap
-}
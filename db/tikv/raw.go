@@ -124,6 +124,40 @@ func (db *rawDB) Scan(ctx context.Context, table string, startKey string, count
 	return res, nil
 }
 
+func (db *rawDB) BatchScan(ctx context.Context, table string, startKeys []string, counts []int, fields []string) ([][]map[string][]byte, error) {
+	res := make([][]map[string][]byte, len(startKeys))
+	for i, startKey := range startKeys {
+		rows, err := db.Scan(ctx, table, startKey, counts[i], fields)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = rows
+	}
+	return res, nil
+}
+
+// ScanKeys implements the KeyScanDB ScanKeys interface.
+func (db *rawDB) ScanKeys(ctx context.Context, table string, startKey string, count int, fields []string) ([]string, []map[string][]byte, error) {
+	// ';' follows ':', so the end key is the end of the table.
+	keys, rows, err := db.db.Scan(ctx, db.getRowKey(table, startKey), util.Slice(table+";"), count)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resKeys := make([]string, len(keys))
+	res := make([]map[string][]byte, len(rows))
+	for i, row := range rows {
+		resKeys[i] = string(keys[i][len(table)+1:])
+		v, err := db.r.Decode(row, fields)
+		if err != nil {
+			return nil, nil, err
+		}
+		res[i] = v
+	}
+
+	return resKeys, res, nil
+}
+
 func (db *rawDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
 	row, err := db.db.Get(ctx, db.getRowKey(table, key))
 	if err != nil {
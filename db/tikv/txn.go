@@ -15,6 +15,7 @@ package tikv
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -180,6 +181,80 @@ func (db *txnDB) Scan(ctx context.Context, table string, startKey string, count
 	return res, nil
 }
 
+// BatchScan scans all the ranges in one transaction, so they are read from
+// the same snapshot.
+func (db *txnDB) BatchScan(ctx context.Context, table string, startKeys []string, counts []int, fields []string) ([][]map[string][]byte, error) {
+	tx, err := db.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res := make([][]map[string][]byte, len(startKeys))
+	for i, startKey := range startKeys {
+		it, err := tx.Iter(db.getRowKey(table, startKey), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		rows := make([]map[string][]byte, 0, counts[i])
+		for j := 0; j < counts[i] && it.Valid(); j++ {
+			v, err := db.r.Decode(it.Value(), fields)
+			if err != nil {
+				it.Close()
+				return nil, err
+			}
+			rows = append(rows, v)
+			if err = it.Next(); err != nil {
+				it.Close()
+				return nil, err
+			}
+		}
+		it.Close()
+		res[i] = rows
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ScanKeys implements the KeyScanDB ScanKeys interface.
+func (db *txnDB) ScanKeys(ctx context.Context, table string, startKey string, count int, fields []string) ([]string, []map[string][]byte, error) {
+	tx, err := db.db.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	// ';' follows ':', so the upper bound is the end of the table.
+	it, err := tx.Iter(db.getRowKey(table, startKey), util.Slice(table+";"))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer it.Close()
+
+	keys := make([]string, 0, count)
+	res := make([]map[string][]byte, 0, count)
+	for i := 0; i < count && it.Valid(); i++ {
+		keys = append(keys, string(it.Key()[len(table)+1:]))
+		v, err := db.r.Decode(it.Value(), fields)
+		if err != nil {
+			return nil, nil, err
+		}
+		res = append(res, v)
+		if err = it.Next(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, nil, err
+	}
+	return keys, res, nil
+}
+
 func (db *txnDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
 	rowKey := db.getRowKey(table, key)
 
@@ -321,3 +396,88 @@ func (db *txnDB) BatchDelete(ctx context.Context, table string, keys []string) e
 	}
 	return tx.Commit(ctx)
 }
+
+// Begin implements the TxnDB Begin interface.
+func (db *txnDB) Begin(ctx context.Context) (ycsb.Txn, error) {
+	tx, err := db.beginTxn()
+	if err != nil {
+		return nil, err
+	}
+	return &tikvTxn{db: db, tx: tx}, nil
+}
+
+// tikvTxn runs the operations of a ycsb.Txn in one TiKV transaction.
+type tikvTxn struct {
+	db *txnDB
+	tx *transaction.KVTxn
+}
+
+// convertTxnErr wraps the retryable conflict errors into ycsb.ErrTxnConflict.
+func convertTxnErr(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var latchErr *tikverr.ErrWriteConflictInLatch
+	var retryableErr *tikverr.ErrRetryable
+	if tikverr.IsErrWriteConflict(err) || errors.As(err, &latchErr) || errors.As(err, &retryableErr) {
+		return fmt.Errorf("%w: %v", ycsb.ErrTxnConflict, err)
+	}
+	return err
+}
+
+func (t *tikvTxn) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	row, err := t.tx.Get(ctx, t.db.getRowKey(table, key))
+	if tikverr.IsErrNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, convertTxnErr(err)
+	}
+
+	return t.db.r.Decode(row, fields)
+}
+
+func (t *tikvTxn) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	rowKey := t.db.getRowKey(table, key)
+	row, err := t.tx.Get(ctx, rowKey)
+	if tikverr.IsErrNotFound(err) {
+		return fmt.Errorf("%w: %s", ycsb.ErrNotFound, key)
+	} else if err != nil {
+		return convertTxnErr(err)
+	}
+
+	data, err := t.db.r.Decode(row, nil)
+	if err != nil {
+		return err
+	}
+
+	for field, value := range values {
+		data[field] = value
+	}
+
+	buf, err := t.db.r.Encode(nil, data)
+	if err != nil {
+		return err
+	}
+	return t.tx.Set(rowKey, buf)
+}
+
+func (t *tikvTxn) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	buf, err := t.db.r.Encode(nil, values)
+	if err != nil {
+		return err
+	}
+	return t.tx.Set(t.db.getRowKey(table, key), buf)
+}
+
+func (t *tikvTxn) Delete(ctx context.Context, table string, key string) error {
+	return t.tx.Delete(t.db.getRowKey(table, key))
+}
+
+func (t *tikvTxn) Commit(ctx context.Context) error {
+	return convertTxnErr(t.tx.Commit(ctx))
+}
+
+func (t *tikvTxn) Rollback(ctx context.Context) error {
+	return t.tx.Rollback()
+}
@@ -6,8 +6,8 @@ import (
 	"fmt"
 	"github.com/magiconair/properties"
 	config2 "github.com/pingcap/go-ycsb/config"
+	"github.com/pingcap/go-ycsb/pkg/util"
 	"github.com/pingcap/go-ycsb/pkg/ycsb"
-	"regexp"
 )
 
 type vrClient struct {
@@ -46,8 +46,7 @@ func (c *vrClient) Update(ctx context.Context, table string, key string, values
 }
 
 func (c *vrClient) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
-	valBytes := encode(values)
-	val := string(valBytes[:])
+	val := util.EncodeFields(values)
 	_, err := c.client.Put(key, val)
 	if err != nil {
 		return err
@@ -81,31 +80,3 @@ func (r vrCreator) Create(p *properties.Properties) (ycsb.DB, error) {
 func init() {
 	ycsb.RegisterDBCreator("vr", vrCreator{})
 }
-
-func encode(values map[string][]byte) []byte {
-	valBytes := make([]byte, 0)
-	for field, val := range values {
-		fieldBytes := []byte(" " + field + "=")
-		valBytes = append(valBytes, fieldBytes...)
-		valBytes = append(valBytes, val...)
-	}
-	return valBytes
-}
-
-func decode(values []byte) map[string][]byte {
-	// Assume the field name is from "field0" to "field9"
-	m := regexp.MustCompile("field[0-9]=")
-	valString := string(values)
-	fields := m.FindAllString(valString, -1)
-	vals := m.Split(valString, -1)
-
-	fieldMap := make(map[string][]byte)
-	for i, val := range vals {
-		// the first val is nil
-		if i == 0 {
-			continue
-		}
-		fieldMap[fields[i-1]] = []byte(val)
-	}
-	return fieldMap
-}
\ No newline at end of file
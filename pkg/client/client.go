@@ -40,6 +40,8 @@ type worker struct {
 	threadID        int
 	targetOpsTickNs int64
 	opsDone         int64
+	schedule        *schedule
+	users           *users
 }
 
 func newWorker(p *properties.Properties, threadID int, threadCount int, workload ycsb.Workload, db ycsb.DB) *worker {
@@ -89,6 +91,24 @@ func newWorker(p *properties.Properties, threadID int, threadCount int, workload
 		w.targetOpsTickNs = int64(1000000.0 / w.targetOpsPerMs)
 	}
 
+	if w.doTransactions {
+		w.users = newUsers(p, threadID)
+	}
+
+	// the operations of the virtual users are due at a time too, and queue up
+	// behind a stalled operation like the throttled ones.
+	if targetPerThreadPerms > 0 || w.users != nil {
+		switch mode := p.GetString(prop.CoordinatedOmission, prop.CoordinatedOmissionDefault); mode {
+		case "both":
+			w.schedule = new(schedule)
+		case "corrected":
+			w.schedule = &schedule{correctedOnly: true}
+		case "uncorrected":
+		default:
+			util.Fatalf("unknown %s %s, must be one of both, corrected or uncorrected", prop.CoordinatedOmission, mode)
+		}
+	}
+
 	return w
 }
 
@@ -108,6 +128,27 @@ func (w *worker) throttle(ctx context.Context, startTime time.Time) {
 	}
 }
 
+// waitUntil waits until the due time of the next virtual user, and returns
+// false if ctx is canceled or the execution time is over meanwhile.
+func (w *worker) waitUntil(ctx context.Context, due time.Time, startTime time.Time, executionTime int64) bool {
+	end := due
+	if executionTime != 0 {
+		if deadline := startTime.Add(time.Duration(executionTime) * time.Second); deadline.Before(end) {
+			end = deadline
+		}
+	}
+	if d := end.Sub(time.Now()); d > 0 {
+		t := time.NewTimer(d)
+		defer t.Stop()
+		select {
+		case <-ctx.Done():
+			return false
+		case <-t.C:
+		}
+	}
+	return !end.Before(due)
+}
+
 func (w *worker) run(ctx context.Context) {
 	// spread the thread operation out so they don't all hit the DB at the same time
 	if w.targetOpsPerMs > 0.0 && w.targetOpsPerMs <= 1.0 {
@@ -117,15 +158,52 @@ func (w *worker) run(ctx context.Context) {
 	startTime := time.Now()
 	executionTime := w.p.GetInt64(prop.MaxExecutiontime, 0)
 
+	// the throttle starts counting after the warm-up, otherwise the operations
+	// skipped during the warm-up would be issued in a burst.
+	throttleStartTime := startTime
+	warmUpFinished := measurement.IsWarmUpFinished()
+	if w.schedule != nil {
+		ctx = context.WithValue(ctx, scheduleKey, w.schedule)
+	}
+	if w.users != nil {
+		w.users.start(ctx, startTime)
+	}
+
 	for w.opCount == 0 || w.opsDone < w.opCount {
+		if !warmUpFinished && measurement.IsWarmUpFinished() {
+			warmUpFinished = true
+			throttleStartTime = time.Now()
+		}
+
+		opCtx := ctx
+		var due time.Time
+		if w.users != nil {
+			u := w.users.next()
+			if !w.waitUntil(ctx, u.due, startTime, executionTime) {
+				return
+			}
+			opCtx, due = u.ctx, u.due
+		}
+		if w.schedule != nil && warmUpFinished {
+			if w.targetOpsPerMs > 0 {
+				if intended := throttleStartTime.Add(time.Duration(w.opsDone * w.targetOpsTickNs)); intended.After(due) {
+					due = intended
+				}
+			}
+			w.schedule.intended = due
+		}
+
 		var err error
 		opsCount := 1
 		if w.doTransactions {
 			if w.doBatch {
-				err = w.workload.DoBatchTransaction(ctx, w.batchSize, w.workerDB)
+				err = w.workload.DoBatchTransaction(opCtx, w.batchSize, w.workerDB)
 				opsCount = w.batchSize
 			} else {
-				err = w.workload.DoTransaction(ctx, w.workerDB)
+				err = w.workload.DoTransaction(opCtx, w.workerDB)
+			}
+			if w.users != nil {
+				w.users.done(time.Now())
 			}
 		} else {
 			if w.doBatch {
@@ -136,13 +214,19 @@ func (w *worker) run(ctx context.Context) {
 			}
 		}
 
+		// a scheduled operation is measured once from its intended start
+		// time, however many DB calls it made and whether it failed.
+		if w.schedule != nil && !w.schedule.intended.IsZero() {
+			measurement.Measure("total_CORRECTED", w.schedule.intended, time.Now().Sub(w.schedule.intended))
+		}
+
 		if err != nil && !w.p.GetBool(prop.Silence, prop.SilenceDefault) {
 			fmt.Printf("operation err: %v\n", err)
 		}
 
-		if measurement.IsWarmUpFinished() {
+		if warmUpFinished {
 			w.opsDone += int64(opsCount)
-			w.throttle(ctx, startTime)
+			w.throttle(ctx, throttleStartTime)
 		}
 
 		if executionTime != 0 {
@@ -215,6 +299,11 @@ func (c *Client) Run(ctx context.Context) {
 	for i := 0; i < threadCount; i++ {
 		dbs[i] = CreateDB(c.dbName, c.p)
 	}
+	if checker, ok := c.workload.(ycsb.DBCheckWorkload); ok {
+		if err := checker.CheckDB(dbs[0]); err != nil {
+			util.Fatalf("workload can't run on %s: %v", c.dbName, err)
+		}
+	}
 
 	for i := 0; i < threadCount; i++ {
 		go func(threadId int) {
@@ -250,6 +339,6 @@ func CreateDB(dbName string, p *properties.Properties) ycsb.DB {
 	if err != nil {
 		util.Fatalf("create db %s failed %v", dbName, err)
 	}
-	db = DbWrapper{db}
+	db = DbWrapper{DB: db}
 	return db
 }
@@ -15,10 +15,13 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/pingcap/go-ycsb/pkg/linearizability"
 	"github.com/pingcap/go-ycsb/pkg/measurement"
+	"github.com/pingcap/go-ycsb/pkg/session"
 	"github.com/pingcap/go-ycsb/pkg/ycsb"
 )
 
@@ -27,15 +30,151 @@ type DbWrapper struct {
 	DB ycsb.DB
 }
 
-func measure(start time.Time, op string, err error) {
-	lan := time.Now().Sub(start)
+type contextKey string
+
+const (
+	scheduleKey = contextKey("schedule")
+	threadKey   = contextKey("thread")
+)
+
+// schedule is the time the throttle intended the current operation to start.
+// It is attached to the worker context and updated before every operation.
+type schedule struct {
+	intended      time.Time
+	correctedOnly bool
+}
+
+func measure(ctx context.Context, start time.Time, op string, err error) {
+	now := time.Now()
+	lan := now.Sub(start)
 	if err != nil {
-		measurement.Measure(fmt.Sprintf("%s_ERROR", op), start, lan)
+		// the workload counts the misses of deleted records on its own.
+		if !ycsb.IsExpectedMiss(ctx) || !errors.Is(err, ycsb.ErrNotFound) {
+			measurement.Measure(fmt.Sprintf("%s_ERROR", op), start, lan)
+			if group := ycsb.MeasureGroup(ctx); group != "" {
+				measurement.Measure(fmt.Sprintf("%s.%s_ERROR", group, op), start, lan)
+			}
+		}
 		return
 	}
 
-	//measurement.Measure(op, start, lan)
-	measurement.Measure("total", start, lan)
+	// Measure the latency from the intended start time as well, so a stalled
+	// server can't hide the delay of the operations queued up behind it. The
+	// worker measures total_CORRECTED once per scheduled operation.
+	correctedOnly := false
+	if s, ok := ctx.Value(scheduleKey).(*schedule); ok && !s.intended.IsZero() {
+		if measurement.IsPerOpEnabled() {
+			measurement.Measure(op+"_CORRECTED", s.intended, now.Sub(s.intended))
+		}
+		correctedOnly = s.correctedOnly
+	}
+
+	if !correctedOnly {
+		measurement.Measure("total", start, lan)
+		if measurement.IsPerOpEnabled() {
+			measurement.Measure(op, start, lan)
+		}
+		if group := ycsb.MeasureGroup(ctx); group != "" {
+			measurement.Measure(group+"."+op, start, lan)
+		}
+	}
+}
+
+func threadID(ctx context.Context) int {
+	id, _ := ctx.Value(threadKey).(int)
+	return id
+}
+
+// callTime returns the call time of an operation in the linearizability
+// history, if the check is enabled.
+func callTime() int64 {
+	if !linearizability.IsEnabled() {
+		return 0
+	}
+	return linearizability.Now()
+}
+
+// afterRead records a read in the linearizability history and checks the
+// session guarantees if enabled, and returns the values without the
+// session headers.
+func afterRead(ctx context.Context, op string, start time.Time, call int64, table string, key string,
+	fields []string, values map[string][]byte, err error) map[string][]byte {
+	if linearizability.IsEnabled() {
+		linearizability.RecordRead(threadID(ctx), table, key, fields, values, call, err == nil)
+	}
+	if s := session.FromContext(ctx); s != nil && err == nil {
+		return s.Read(op, start, table, key, values)
+	}
+	return values
+}
+
+// beforeWrite returns the values with the session header if the session
+// guarantees are checked.
+func beforeWrite(ctx context.Context, values map[string][]byte) (map[string][]byte, uint64) {
+	if s := session.FromContext(ctx); s != nil {
+		return s.Stamp(values)
+	}
+	return values, 0
+}
+
+// afterWrite records a write in the linearizability history and in the
+// session if enabled. ok is false if the write may not have been applied.
+func afterWrite(ctx context.Context, call int64, table string, key string, values map[string][]byte, seq uint64, ok bool) {
+	if linearizability.IsEnabled() {
+		linearizability.RecordWrite(threadID(ctx), table, key, values, call, ok)
+	}
+	if s := session.FromContext(ctx); s != nil && ok {
+		s.Wrote(table, key, values, seq)
+	}
+}
+
+func beforeBatchWrite(ctx context.Context, values []map[string][]byte) ([]map[string][]byte, []uint64) {
+	stamped := make([]map[string][]byte, len(values))
+	seqs := make([]uint64, len(values))
+	for i := range values {
+		stamped[i], seqs[i] = beforeWrite(ctx, values[i])
+	}
+	return stamped, seqs
+}
+
+// read, update, insert and remove run the operation on the DB, record it in
+// the linearizability history and check the session guarantees if enabled.
+func (db DbWrapper) read(ctx context.Context, op string, table string, key string, fields []string) (map[string][]byte, error) {
+	start := time.Now()
+	call := callTime()
+	values, err := db.DB.Read(ctx, table, key, fields)
+	return afterRead(ctx, op, start, call, table, key, fields, values, err), err
+}
+
+func (db DbWrapper) update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	values, seq := beforeWrite(ctx, values)
+	call := callTime()
+	err := db.DB.Update(ctx, table, key, values)
+	// an update of a deleted record may or may not write it.
+	afterWrite(ctx, call, table, key, values, seq, err == nil && !ycsb.IsExpectedMiss(ctx))
+	return err
+}
+
+func (db DbWrapper) insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	values, seq := beforeWrite(ctx, values)
+	call := callTime()
+	err := db.DB.Insert(ctx, table, key, values)
+	afterWrite(ctx, call, table, key, values, seq, err == nil)
+	return err
+}
+
+func (db DbWrapper) remove(ctx context.Context, table string, key string) error {
+	call := callTime()
+	err := db.DB.Delete(ctx, table, key)
+	if linearizability.IsEnabled() {
+		linearizability.RecordDelete(threadID(ctx), table, key, call, err == nil)
+	}
+	return err
+}
+
+// Unwrap implements the ycsb.Unwrapper interface.
+func (db DbWrapper) Unwrap() ycsb.DB {
+	return db.DB
 }
 
 func (db DbWrapper) Close() error {
@@ -43,6 +182,10 @@ func (db DbWrapper) Close() error {
 }
 
 func (db DbWrapper) InitThread(ctx context.Context, threadID int, threadCount int) context.Context {
+	ctx = context.WithValue(ctx, threadKey, threadID)
+	if session.IsEnabled() {
+		ctx = session.NewContext(ctx)
+	}
 	return db.DB.InitThread(ctx, threadID, threadCount)
 }
 
@@ -53,59 +196,113 @@ func (db DbWrapper) CleanupThread(ctx context.Context) {
 func (db DbWrapper) Read(ctx context.Context, table string, key string, fields []string) (_ map[string][]byte, err error) {
 	start := time.Now()
 	defer func() {
-		measure(start, "READ", err)
+		measure(ctx, start, "READ", err)
 	}()
 
-	return db.DB.Read(ctx, table, key, fields)
+	return db.read(ctx, "READ", table, key, fields)
 }
 
 func (db DbWrapper) BatchRead(ctx context.Context, table string, keys []string, fields []string) (_ []map[string][]byte, err error) {
+	start := time.Now()
+	defer func() {
+		measure(ctx, start, "BATCH_READ", err)
+	}()
+
 	batchDB, ok := db.DB.(ycsb.BatchDB)
 	if ok {
-		start := time.Now()
-		defer func() {
-			measure(start, "BATCH_READ", err)
-		}()
-		return batchDB.BatchRead(ctx, table, keys, fields)
+		call := callTime()
+		values, err := batchDB.BatchRead(ctx, table, keys, fields)
+		if len(values) == len(keys) {
+			for i, key := range keys {
+				values[i] = afterRead(ctx, "BATCH_READ", start, call, table, key, fields, values[i], err)
+			}
+		}
+		return values, err
 	}
-	for _, key := range keys {
-		_, err := db.DB.Read(ctx, table, key, fields)
+	values := make([]map[string][]byte, len(keys))
+	for i, key := range keys {
+		values[i], err = db.read(ctx, "BATCH_READ", table, key, fields)
 		if err != nil {
 			return nil, err
 		}
 	}
-	return nil, nil
+	return values, nil
 }
 
 func (db DbWrapper) Scan(ctx context.Context, table string, startKey string, count int, fields []string) (_ []map[string][]byte, err error) {
 	start := time.Now()
 	defer func() {
-		measure(start, "SCAN", err)
+		measure(ctx, start, "SCAN", err)
 	}()
 
-	return db.DB.Scan(ctx, table, startKey, count, fields)
+	return db.scan(ctx, table, startKey, count, fields)
+}
+
+func (db DbWrapper) scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
+	rows, err := db.DB.Scan(ctx, table, startKey, count, fields)
+	if session.FromContext(ctx) != nil {
+		for i := range rows {
+			rows[i] = session.Strip(rows[i])
+		}
+	}
+	return rows, err
+}
+
+func (db DbWrapper) BatchScan(ctx context.Context, table string, startKeys []string, counts []int, fields []string) (_ [][]map[string][]byte, err error) {
+	start := time.Now()
+	defer func() {
+		measure(ctx, start, "BATCH_SCAN", err)
+	}()
+
+	batchDB, ok := db.DB.(ycsb.BatchDB)
+	if ok {
+		res, err := batchDB.BatchScan(ctx, table, startKeys, counts, fields)
+		if session.FromContext(ctx) != nil {
+			for _, rows := range res {
+				for i := range rows {
+					rows[i] = session.Strip(rows[i])
+				}
+			}
+		}
+		return res, err
+	}
+	res := make([][]map[string][]byte, len(startKeys))
+	for i, startKey := range startKeys {
+		res[i], err = db.scan(ctx, table, startKey, counts[i], fields)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
 }
 
 func (db DbWrapper) Update(ctx context.Context, table string, key string, values map[string][]byte) (err error) {
 	start := time.Now()
 	defer func() {
-		measure(start, "UPDATE", err)
+		measure(ctx, start, "UPDATE", err)
 	}()
 
-	return db.DB.Update(ctx, table, key, values)
+	return db.update(ctx, table, key, values)
 }
 
 func (db DbWrapper) BatchUpdate(ctx context.Context, table string, keys []string, values []map[string][]byte) (err error) {
+	start := time.Now()
+	defer func() {
+		measure(ctx, start, "BATCH_UPDATE", err)
+	}()
+
 	batchDB, ok := db.DB.(ycsb.BatchDB)
 	if ok {
-		start := time.Now()
-		defer func() {
-			measure(start, "BATCH_UPDATE", err)
-		}()
-		return batchDB.BatchUpdate(ctx, table, keys, values)
+		stamped, seqs := beforeBatchWrite(ctx, values)
+		call := callTime()
+		err = batchDB.BatchUpdate(ctx, table, keys, stamped)
+		for i, key := range keys {
+			afterWrite(ctx, call, table, key, stamped[i], seqs[i], err == nil && !ycsb.IsExpectedMiss(ctx))
+		}
+		return err
 	}
 	for i := range keys {
-		err := db.DB.Update(ctx, table, keys[i], values[i])
+		err := db.update(ctx, table, keys[i], values[i])
 		if err != nil {
 			return err
 		}
@@ -116,23 +313,30 @@ func (db DbWrapper) BatchUpdate(ctx context.Context, table string, keys []string
 func (db DbWrapper) Insert(ctx context.Context, table string, key string, values map[string][]byte) (err error) {
 	start := time.Now()
 	defer func() {
-		measure(start, "INSERT", err)
+		measure(ctx, start, "INSERT", err)
 	}()
 
-	return db.DB.Insert(ctx, table, key, values)
+	return db.insert(ctx, table, key, values)
 }
 
 func (db DbWrapper) BatchInsert(ctx context.Context, table string, keys []string, values []map[string][]byte) (err error) {
+	start := time.Now()
+	defer func() {
+		measure(ctx, start, "BATCH_INSERT", err)
+	}()
+
 	batchDB, ok := db.DB.(ycsb.BatchDB)
 	if ok {
-		start := time.Now()
-		defer func() {
-			measure(start, "BATCH_INSERT", err)
-		}()
-		return batchDB.BatchInsert(ctx, table, keys, values)
+		stamped, seqs := beforeBatchWrite(ctx, values)
+		call := callTime()
+		err = batchDB.BatchInsert(ctx, table, keys, stamped)
+		for i, key := range keys {
+			afterWrite(ctx, call, table, key, stamped[i], seqs[i], err == nil)
+		}
+		return err
 	}
 	for i := range keys {
-		err := db.DB.Insert(ctx, table, keys[i], values[i])
+		err := db.insert(ctx, table, keys[i], values[i])
 		if err != nil {
 			return err
 		}
@@ -143,23 +347,31 @@ func (db DbWrapper) BatchInsert(ctx context.Context, table string, keys []string
 func (db DbWrapper) Delete(ctx context.Context, table string, key string) (err error) {
 	start := time.Now()
 	defer func() {
-		measure(start, "DELETE", err)
+		measure(ctx, start, "DELETE", err)
 	}()
 
-	return db.DB.Delete(ctx, table, key)
+	return db.remove(ctx, table, key)
 }
 
 func (db DbWrapper) BatchDelete(ctx context.Context, table string, keys []string) (err error) {
+	start := time.Now()
+	defer func() {
+		measure(ctx, start, "BATCH_DELETE", err)
+	}()
+
 	batchDB, ok := db.DB.(ycsb.BatchDB)
 	if ok {
-		start := time.Now()
-		defer func() {
-			measure(start, "BATCH_DELETE", err)
-		}()
-		return batchDB.BatchDelete(ctx, table, keys)
+		call := callTime()
+		err = batchDB.BatchDelete(ctx, table, keys)
+		if linearizability.IsEnabled() {
+			for _, key := range keys {
+				linearizability.RecordDelete(threadID(ctx), table, key, call, err == nil)
+			}
+		}
+		return err
 	}
 	for _, key := range keys {
-		err := db.DB.Delete(ctx, table, key)
+		err := db.remove(ctx, table, key)
 		if err != nil {
 			return err
 		}
@@ -173,3 +385,252 @@ func (db DbWrapper) Analyze(ctx context.Context, table string) error {
 	}
 	return nil
 }
+
+func (db DbWrapper) ReadVersion(ctx context.Context, table string, key string, fields []string) (_ map[string][]byte, _ int64, err error) {
+	casDB, ok := db.DB.(ycsb.CASDB)
+	if !ok {
+		return nil, 0, fmt.Errorf("the %T doesn't implement the CASDB interface", db.DB)
+	}
+
+	start := time.Now()
+	defer func() {
+		measure(ctx, start, "READ_VERSION", err)
+	}()
+
+	call := callTime()
+	values, version, err := casDB.ReadVersion(ctx, table, key, fields)
+	return afterRead(ctx, "READ_VERSION", start, call, table, key, fields, values, err), version, err
+}
+
+func (db DbWrapper) CompareAndSwap(ctx context.Context, table string, key string, version int64, values map[string][]byte) (_ bool, err error) {
+	casDB, ok := db.DB.(ycsb.CASDB)
+	if !ok {
+		return false, fmt.Errorf("the %T doesn't implement the CASDB interface", db.DB)
+	}
+
+	start := time.Now()
+	defer func() {
+		measure(ctx, start, "COMPARE_AND_SWAP", err)
+	}()
+
+	values, seq := beforeWrite(ctx, values)
+	call := callTime()
+	applied, err := casDB.CompareAndSwap(ctx, table, key, version, values)
+	// a swap known to have failed wrote nothing.
+	if applied || err != nil {
+		afterWrite(ctx, call, table, key, values, seq, applied)
+	}
+	return applied, err
+}
+
+func (db DbWrapper) GrantLease(ctx context.Context, ttl time.Duration) (_ int64, _ time.Duration, err error) {
+	leaseDB, ok := db.DB.(ycsb.LeaseDB)
+	if !ok {
+		return 0, 0, fmt.Errorf("the %T doesn't implement the LeaseDB interface", db.DB)
+	}
+
+	start := time.Now()
+	defer func() {
+		measure(ctx, start, "LEASE_GRANT", err)
+	}()
+
+	return leaseDB.GrantLease(ctx, ttl)
+}
+
+// PutWithLease is not recorded in the linearizability history nor in the
+// session, the records vanish when their lease expires.
+func (db DbWrapper) PutWithLease(ctx context.Context, table string, key string, values map[string][]byte, lease int64) (err error) {
+	leaseDB, ok := db.DB.(ycsb.LeaseDB)
+	if !ok {
+		return fmt.Errorf("the %T doesn't implement the LeaseDB interface", db.DB)
+	}
+
+	start := time.Now()
+	defer func() {
+		measure(ctx, start, "LEASE_PUT", err)
+	}()
+
+	return leaseDB.PutWithLease(ctx, table, key, values, lease)
+}
+
+func (db DbWrapper) KeepAliveLease(ctx context.Context, lease int64) (err error) {
+	leaseDB, ok := db.DB.(ycsb.LeaseDB)
+	if !ok {
+		return fmt.Errorf("the %T doesn't implement the LeaseDB interface", db.DB)
+	}
+
+	start := time.Now()
+	defer func() {
+		measure(ctx, start, "LEASE_KEEPALIVE", err)
+	}()
+
+	return leaseDB.KeepAliveLease(ctx, lease)
+}
+
+func (db DbWrapper) RevokeLease(ctx context.Context, lease int64) (err error) {
+	leaseDB, ok := db.DB.(ycsb.LeaseDB)
+	if !ok {
+		return fmt.Errorf("the %T doesn't implement the LeaseDB interface", db.DB)
+	}
+
+	start := time.Now()
+	defer func() {
+		measure(ctx, start, "LEASE_REVOKE", err)
+	}()
+
+	return leaseDB.RevokeLease(ctx, lease)
+}
+
+func (db DbWrapper) Watch(ctx context.Context, table string) (_ <-chan ycsb.WatchEvent, err error) {
+	watchDB, ok := db.DB.(ycsb.WatchDB)
+	if !ok {
+		return nil, fmt.Errorf("the %T doesn't implement the WatchDB interface", db.DB)
+	}
+
+	start := time.Now()
+	defer func() {
+		measure(ctx, start, "WATCH", err)
+	}()
+
+	ch, err := watchDB.Watch(ctx, table)
+	if err != nil || session.FromContext(ctx) == nil {
+		return ch, err
+	}
+	return stripEvents(ctx, ch), nil
+}
+
+// stripEvents returns the events of ch without the session headers.
+func stripEvents(ctx context.Context, ch <-chan ycsb.WatchEvent) <-chan ycsb.WatchEvent {
+	stripped := make(chan ycsb.WatchEvent)
+	go func() {
+		defer close(stripped)
+		for ev := range ch {
+			ev.Values = session.Strip(ev.Values)
+			select {
+			case stripped <- ev:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return stripped
+}
+
+func (db DbWrapper) Begin(ctx context.Context) (_ ycsb.Txn, err error) {
+	txnDB, ok := db.DB.(ycsb.TxnDB)
+	if !ok {
+		return nil, fmt.Errorf("the %T doesn't implement the TxnDB interface", db.DB)
+	}
+
+	start := time.Now()
+	defer func() {
+		measure(ctx, start, "TXN_BEGIN", err)
+	}()
+
+	txn, err := txnDB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &txnWrapper{txn: txn}, nil
+}
+
+// txnWrapper measures the operations of a ycsb.Txn. Its writes are stamped
+// like the other writes, and recorded once the transaction commits.
+type txnWrapper struct {
+	txn    ycsb.Txn
+	writes []txnWrite
+}
+
+type txnWrite struct {
+	call    int64
+	table   string
+	key     string
+	values  map[string][]byte
+	seq     uint64
+	deleted bool
+}
+
+func (t *txnWrapper) Read(ctx context.Context, table string, key string, fields []string) (_ map[string][]byte, err error) {
+	start := time.Now()
+	defer func() {
+		measure(ctx, start, "TXN_READ", err)
+	}()
+
+	values, err := t.txn.Read(ctx, table, key, fields)
+	if session.FromContext(ctx) != nil {
+		values = session.Strip(values)
+	}
+	return values, err
+}
+
+func (t *txnWrapper) Update(ctx context.Context, table string, key string, values map[string][]byte) (err error) {
+	start := time.Now()
+	defer func() {
+		measure(ctx, start, "TXN_UPDATE", err)
+	}()
+
+	values, seq := beforeWrite(ctx, values)
+	call := callTime()
+	if err = t.txn.Update(ctx, table, key, values); err == nil {
+		t.writes = append(t.writes, txnWrite{call: call, table: table, key: key, values: values, seq: seq})
+	}
+	return err
+}
+
+func (t *txnWrapper) Insert(ctx context.Context, table string, key string, values map[string][]byte) (err error) {
+	start := time.Now()
+	defer func() {
+		measure(ctx, start, "TXN_INSERT", err)
+	}()
+
+	values, seq := beforeWrite(ctx, values)
+	call := callTime()
+	if err = t.txn.Insert(ctx, table, key, values); err == nil {
+		t.writes = append(t.writes, txnWrite{call: call, table: table, key: key, values: values, seq: seq})
+	}
+	return err
+}
+
+func (t *txnWrapper) Delete(ctx context.Context, table string, key string) (err error) {
+	start := time.Now()
+	defer func() {
+		measure(ctx, start, "TXN_DELETE", err)
+	}()
+
+	call := callTime()
+	if err = t.txn.Delete(ctx, table, key); err == nil {
+		t.writes = append(t.writes, txnWrite{call: call, table: table, key: key, deleted: true})
+	}
+	return err
+}
+
+func (t *txnWrapper) Commit(ctx context.Context) (err error) {
+	start := time.Now()
+	defer func() {
+		measure(ctx, start, "TXN_COMMIT", err)
+	}()
+
+	err = t.txn.Commit(ctx)
+	// a failed commit may or may not have applied the writes.
+	for _, w := range t.writes {
+		if w.deleted {
+			if linearizability.IsEnabled() {
+				linearizability.RecordDelete(threadID(ctx), w.table, w.key, w.call, err == nil)
+			}
+			continue
+		}
+		afterWrite(ctx, w.call, w.table, w.key, w.values, w.seq, err == nil)
+	}
+	t.writes = nil
+	return err
+}
+
+func (t *txnWrapper) Rollback(ctx context.Context) (err error) {
+	start := time.Now()
+	defer func() {
+		measure(ctx, start, "TXN_ROLLBACK", err)
+	}()
+
+	t.writes = nil
+	return t.txn.Rollback(ctx)
+}
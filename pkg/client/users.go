@@ -0,0 +1,162 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/generator"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// virtualUser is a user of the application, issuing the operations of its
+// sessions with a think time between them.
+type virtualUser struct {
+	ctx     context.Context
+	session ycsb.UserSession
+	// opsLeft is the number of operations left in the session.
+	opsLeft int64
+	// due is when the user issues its next operation.
+	due time.Time
+}
+
+// userQueue orders the users by their due time.
+type userQueue []*virtualUser
+
+func (q userQueue) Len() int            { return len(q) }
+func (q userQueue) Less(i, j int) bool  { return q[i].due.Before(q[j].due) }
+func (q userQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *userQueue) Push(x interface{}) { *q = append(*q, x.(*virtualUser)) }
+func (q *userQueue) Pop() interface{} {
+	old := *q
+	u := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return u
+}
+
+// users multiplexes the virtual users of a worker on its goroutine, the user
+// due first issues the next operation. Idle users cost no goroutine, so a
+// few threads can simulate many mostly idle clients.
+type users struct {
+	r     *rand.Rand
+	count int64
+	first int64
+	// thinkTime and idleTime generate milliseconds, sessionOps is nil for
+	// one endless session per user.
+	thinkTime  ycsb.Generator
+	idleTime   ycsb.Generator
+	sessionOps ycsb.Generator
+	queue      userQueue
+}
+
+// parseUserSpec parses the distribution spec of a user property, a negative
+// value is clamped to min.
+func parseUserSpec(name string, spec string, min int64, max int64) ycsb.Generator {
+	if spec == "" {
+		return nil
+	}
+	gen, err := generator.ParseSpec(spec, min, max)
+	if err != nil {
+		util.Fatalf("bad %s %s: %v", name, spec, err)
+	}
+	return generator.NewClamp(gen, min, math.MaxInt64)
+}
+
+// newUsers returns the virtual users of a thread, or nil if the thread runs
+// its operations back to back as a single user.
+func newUsers(p *properties.Properties, threadID int) *users {
+	count := p.GetInt64(prop.UserCount, prop.UserCountDefault)
+	thinkTimeName := fmt.Sprintf("%s.%d", prop.UserThinkTime, threadID)
+	if _, ok := p.Get(thinkTimeName); !ok {
+		thinkTimeName = prop.UserThinkTime
+	}
+	thinkTime := p.GetString(thinkTimeName, "")
+	sessionOps := p.GetString(prop.UserSessionOps, "")
+	if count <= 1 && thinkTime == "" && sessionOps == "" {
+		return nil
+	}
+	if count < 1 {
+		util.Fatalf("%s must be positive", prop.UserCount)
+	}
+
+	return &users{
+		r:          rand.New(rand.NewSource(time.Now().UnixNano() + int64(threadID))),
+		count:      count,
+		first:      int64(threadID) * count,
+		thinkTime:  parseUserSpec(thinkTimeName, thinkTime, 0, 60000),
+		idleTime:   parseUserSpec(prop.UserIdleTime, p.GetString(prop.UserIdleTime, ""), 0, 60000),
+		sessionOps: parseUserSpec(prop.UserSessionOps, sessionOps, 1, 100),
+	}
+}
+
+func (us *users) pause(gen ycsb.Generator) time.Duration {
+	if gen == nil {
+		return 0
+	}
+	return time.Duration(gen.Next(us.r)) * time.Millisecond
+}
+
+// begin starts the next session of u.
+func (us *users) begin(u *virtualUser) {
+	u.session.Begin()
+	if us.sessionOps != nil {
+		u.opsLeft = us.sessionOps.Next(us.r)
+	}
+}
+
+// start creates the users, their first sessions start after an idle time,
+// or a think time without sessions, so they don't all start at once.
+func (us *users) start(ctx context.Context, now time.Time) {
+	first := us.thinkTime
+	if us.sessionOps != nil {
+		first = us.idleTime
+	}
+	us.queue = make(userQueue, 0, us.count)
+	for i := int64(0); i < us.count; i++ {
+		u := &virtualUser{due: now.Add(us.pause(first))}
+		u.session.User = us.first + i
+		u.ctx = ycsb.WithUserSession(ctx, &u.session)
+		us.begin(u)
+		us.queue = append(us.queue, u)
+	}
+	heap.Init(&us.queue)
+}
+
+// next returns the user due first.
+func (us *users) next() *virtualUser {
+	return us.queue[0]
+}
+
+// done schedules the next operation of the user returned by next, after a
+// think time, or after an idle time in a new session once its session is over.
+func (us *users) done(now time.Time) {
+	u := us.queue[0]
+	u.session.Op++
+	u.opsLeft--
+	if us.sessionOps != nil && u.opsLeft <= 0 {
+		us.begin(u)
+		u.due = now.Add(us.pause(us.idleTime))
+	} else {
+		u.due = now.Add(us.pause(us.thinkTime))
+	}
+	heap.Fix(&us.queue, 0)
+}
@@ -0,0 +1,121 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"math/rand"
+
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// Mix generates integers from one of several generators, chosen by weight.
+type Mix struct {
+	Number
+	weights    []float64
+	generators []ycsb.Generator
+	total      float64
+}
+
+// NewMix creates an empty Mix generator.
+func NewMix() *Mix {
+	return &Mix{}
+}
+
+// Add adds a generator with weight.
+func (m *Mix) Add(weight float64, gen ycsb.Generator) {
+	m.weights = append(m.weights, weight)
+	m.generators = append(m.generators, gen)
+	m.total += weight
+}
+
+// Next implements the Generator Next interface.
+func (m *Mix) Next(r *rand.Rand) int64 {
+	val := r.Float64() * m.total
+	i := 0
+	for ; i < len(m.weights)-1; i++ {
+		if val < m.weights[i] {
+			break
+		}
+		val -= m.weights[i]
+	}
+	value := m.generators[i].Next(r)
+	m.SetLastValue(value)
+	return value
+}
+
+// Clamp bounds the integers of a generator to [min, max].
+type Clamp struct {
+	Number
+	gen ycsb.Generator
+	min int64
+	max int64
+}
+
+// NewClamp creates a Clamp generator.
+func NewClamp(gen ycsb.Generator, min int64, max int64) *Clamp {
+	return &Clamp{gen: gen, min: min, max: max}
+}
+
+// Next implements the Generator Next interface.
+func (c *Clamp) Next(r *rand.Rand) int64 {
+	value := c.gen.Next(r)
+	if value < c.min {
+		value = c.min
+	} else if value > c.max {
+		value = c.max
+	}
+	c.SetLastValue(value)
+	return value
+}
+
+// Scramble hashes the integers of a generator over [min, max], so the
+// popular items are scattered throughout the range instead of clustered.
+type Scramble struct {
+	Number
+	gen       ycsb.Generator
+	min       int64
+	itemCount int64
+}
+
+// NewScramble creates a Scramble generator.
+func NewScramble(gen ycsb.Generator, min int64, max int64) *Scramble {
+	return &Scramble{gen: gen, min: min, itemCount: max - min + 1}
+}
+
+// Next implements the Generator Next interface.
+func (s *Scramble) Next(r *rand.Rand) int64 {
+	value := s.min + util.Hash64(s.gen.Next(r))%s.itemCount
+	s.SetLastValue(value)
+	return value
+}
+
+// Offset adds a constant to the integers of a generator.
+type Offset struct {
+	Number
+	gen ycsb.Generator
+	by  int64
+}
+
+// NewOffset creates an Offset generator.
+func NewOffset(gen ycsb.Generator, by int64) *Offset {
+	return &Offset{gen: gen, by: by}
+}
+
+// Next implements the Generator Next interface.
+func (o *Offset) Next(r *rand.Rand) int64 {
+	value := o.gen.Next(r) + o.by
+	o.SetLastValue(value)
+	return value
+}
@@ -0,0 +1,74 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import "math/rand"
+
+// Conflict generates integers like the "conflict" distribution of the Paxi
+// benchmark: a percentage of the operations hit the shared keys at the lower
+// bound, and the others walk through the private keys of the client in
+// order. Every client has its own Conflict generator.
+type Conflict struct {
+	Number
+	sharedLower  int64
+	sharedCount  int64
+	privateLower int64
+	privateCount int64
+	conflicts    float64
+	counter      int64
+}
+
+// NewConflict creates a Conflict generator for one of clientCount clients.
+// lowerBound: the lower bound of the distribution.
+// upperBound: the upper bound of the distribution.
+// sharedKeys: the number of keys shared by all the clients, starting at lowerBound.
+// conflicts: percentage of operations accessing the shared keys, in [0, 100].
+// The other keys are split evenly into the private keys of every client.
+func NewConflict(lowerBound int64, upperBound int64, sharedKeys int64, conflicts float64, clientID int, clientCount int) *Conflict {
+	if lowerBound > upperBound {
+		lowerBound, upperBound = upperBound, lowerBound
+	}
+	interval := upperBound - lowerBound + 1
+	if sharedKeys < 1 {
+		sharedKeys = 1
+	}
+	if sharedKeys > interval {
+		sharedKeys = interval
+	}
+	if clientCount < 1 {
+		clientCount = 1
+	}
+
+	c := &Conflict{
+		sharedLower:  lowerBound,
+		sharedCount:  sharedKeys,
+		privateCount: (interval - sharedKeys) / int64(clientCount),
+		conflicts:    conflicts,
+	}
+	c.privateLower = lowerBound + sharedKeys + int64(clientID)*c.privateCount
+	return c
+}
+
+// Next implements the Generator Next interface.
+func (c *Conflict) Next(r *rand.Rand) int64 {
+	var value int64
+	if c.privateCount == 0 || r.Float64()*100 < c.conflicts {
+		value = c.sharedLower + r.Int63n(c.sharedCount)
+	} else {
+		c.counter = (c.counter + 1) % c.privateCount
+		value = c.privateLower + c.counter
+	}
+	c.SetLastValue(value)
+	return value
+}
@@ -0,0 +1,119 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"math/rand"
+	"time"
+)
+
+// shiftingHotspot generates integers resembling a hotspot distribution whose
+// hot set starts at an offset changing over time. The hot set wraps around
+// the upper bound.
+type shiftingHotspot struct {
+	Number
+	lowerBound     int64
+	interval       int64
+	hotInterval    int64
+	hotOpnFraction float64
+	start          time.Time
+	offset         func(elapsed time.Duration) int64
+}
+
+func newShiftingHotspot(lowerBound int64, upperBound int64, hotsetFraction float64, hotOpnFraction float64) shiftingHotspot {
+	if hotsetFraction < 0.0 || hotsetFraction > 1.0 {
+		hotsetFraction = 0.0
+	}
+
+	if hotOpnFraction < 0.0 || hotOpnFraction > 1.0 {
+		hotOpnFraction = 0.0
+	}
+
+	if lowerBound > upperBound {
+		lowerBound, upperBound = upperBound, lowerBound
+	}
+
+	interval := upperBound - lowerBound + 1
+	hotInterval := int64(float64(interval) * hotsetFraction)
+	if hotInterval < 1 {
+		hotInterval = 1
+	}
+	return shiftingHotspot{
+		lowerBound:     lowerBound,
+		interval:       interval,
+		hotInterval:    hotInterval,
+		hotOpnFraction: hotOpnFraction,
+		start:          time.Now(),
+	}
+}
+
+// Next implements the Generator Next interface.
+func (h *shiftingHotspot) Next(r *rand.Rand) int64 {
+	value := h.offset(time.Since(h.start))
+	coldInterval := h.interval - h.hotInterval
+	if coldInterval == 0 || r.Float64() < h.hotOpnFraction {
+		value += r.Int63n(h.hotInterval)
+	} else {
+		value += h.hotInterval + r.Int63n(coldInterval)
+	}
+	value = h.lowerBound + value%h.interval
+	h.SetLastValue(value)
+	return value
+}
+
+// MovingHotspot generates integers resembling a hotspot distribution whose hot
+// set drifts towards the upper bound at a constant speed.
+type MovingHotspot struct {
+	shiftingHotspot
+}
+
+// NewMovingHotspot creates a MovingHotspot generator.
+// lowerBound: the lower bound of the distribution.
+// upperBound: the upper bound of the distribution.
+// hotsetFraction: percentage of data items in the hot set.
+// hotOpnFraction: percentage of operations accessing the hot set.
+// speed: the number of keys the hot set moves by per second.
+func NewMovingHotspot(lowerBound int64, upperBound int64, hotsetFraction float64, hotOpnFraction float64, speed float64) *MovingHotspot {
+	h := &MovingHotspot{newShiftingHotspot(lowerBound, upperBound, hotsetFraction, hotOpnFraction)}
+	h.offset = func(elapsed time.Duration) int64 {
+		return int64(elapsed.Seconds()*speed) % h.interval
+	}
+	return h
+}
+
+// JumpingHotspot generates integers resembling a hotspot distribution whose
+// hot set jumps to another place of the key range every period.
+type JumpingHotspot struct {
+	shiftingHotspot
+}
+
+// NewJumpingHotspot creates a JumpingHotspot generator.
+// lowerBound: the lower bound of the distribution.
+// upperBound: the upper bound of the distribution.
+// hotsetFraction: percentage of data items in the hot set.
+// hotOpnFraction: percentage of operations accessing the hot set.
+// period: how long the hot set stays at one place.
+func NewJumpingHotspot(lowerBound int64, upperBound int64, hotsetFraction float64, hotOpnFraction float64, period time.Duration) *JumpingHotspot {
+	h := &JumpingHotspot{newShiftingHotspot(lowerBound, upperBound, hotsetFraction, hotOpnFraction)}
+	h.offset = func(elapsed time.Duration) int64 {
+		if period <= 0 {
+			return 0
+		}
+		// Scatter the places with the golden ratio, so all the threads
+		// agree on them without sharing any state.
+		jumps := uint64(elapsed / period)
+		return int64(jumps * 0x9E3779B97F4A7C15 % uint64(h.interval))
+	}
+	return h
+}
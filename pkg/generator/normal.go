@@ -0,0 +1,69 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Normal generates integers like the "normal" distribution of the Paxi
+// benchmark: a normal distribution with mean mu and standard deviation sigma
+// over the offsets from the lower bound, wrapped around into the key range.
+// If speed is positive, mu moves up by one key every speed.
+type Normal struct {
+	Number
+	lowerBound int64
+	interval   int64
+	mu         float64
+	sigma      float64
+	speed      time.Duration
+	start      time.Time
+}
+
+// NewNormal creates a Normal generator.
+// lowerBound: the lower bound of the distribution.
+// upperBound: the upper bound of the distribution.
+// mu: the mean, as an offset from lowerBound.
+// sigma: the standard deviation.
+// speed: how long mu stays on a key before moving to the next one, 0 for a fixed mu.
+func NewNormal(lowerBound int64, upperBound int64, mu float64, sigma float64, speed time.Duration) *Normal {
+	if lowerBound > upperBound {
+		lowerBound, upperBound = upperBound, lowerBound
+	}
+	return &Normal{
+		lowerBound: lowerBound,
+		interval:   upperBound - lowerBound + 1,
+		mu:         mu,
+		sigma:      sigma,
+		speed:      speed,
+		start:      time.Now(),
+	}
+}
+
+// Next implements the Generator Next interface.
+func (n *Normal) Next(r *rand.Rand) int64 {
+	mu := n.mu
+	if n.speed > 0 {
+		mu += float64(time.Since(n.start) / n.speed)
+	}
+	offset := int64(math.Floor(r.NormFloat64()*n.sigma+mu)) % n.interval
+	if offset < 0 {
+		offset += n.interval
+	}
+	value := n.lowerBound + offset
+	n.SetLastValue(value)
+	return value
+}
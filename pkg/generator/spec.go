@@ -0,0 +1,441 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// A distribution spec describes a generator tree, for example
+//
+//	mix(0.8: zipfian(theta=1.2), 0.2: uniform)
+//	clamp(exponential(mean=50), 1, 1000)
+//
+// A spec is a distribution name, optionally followed by arguments in
+// parentheses. An argument is a number, a name=number pair, a nested spec,
+// or a weight: spec pair for mix. Every distribution generates integers in
+// the range given by its parent, [lb, ub] for the root.
+//
+// The distributions are:
+//
+//	uniform(min, max)              uniformly in [min, max], the range by default
+//	sequential(min, max)           min, min+1, ..., max, min, ...
+//	constant(value)                always value
+//	zipfian(min, max, theta=0.99)  zipfian in [min, max] with min the most popular
+//	hotspot(data=0.2, ops=0.8)     ops of the operations access data of the range
+//	movinghotspot(data, ops, speed=100)    a hotspot moving by speed keys per second
+//	jumpinghotspot(data, ops, period=10)   a hotspot jumping every period seconds
+//	normal(mu, sigma, speed=0)     normal around the range start plus mu, wrapped
+//	                               around, mu moves by one key every speed ms
+//	exponential(mean)              the range start plus an exponential variate,
+//	                               the variates past the range end are clamped to it
+//	exponential(percentile, range) percentile of the variates are below range
+//	mix(w1: spec1, w2: spec2, ...) spec1 with weight w1, spec2 with weight w2, ...
+//	clamp(spec, min, max)          spec in [min, max], which is also the range of spec
+//	scramble(spec)                 spec hashed over the range, so the popular items
+//	                               are scattered instead of clustered
+
+type specArg struct {
+	name      string
+	weight    float64
+	hasWeight bool
+	number    float64
+	spec      *spec
+}
+
+type spec struct {
+	name string
+	args []specArg
+}
+
+type specParser struct {
+	s   string
+	pos int
+}
+
+func (p *specParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("bad distribution spec %q at %d: %s", p.s, p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *specParser) skipSpaces() {
+	for p.pos < len(p.s) && unicode.IsSpace(rune(p.s[p.pos])) {
+		p.pos++
+	}
+}
+
+// peek returns the next non-space byte, or 0 at the end.
+func (p *specParser) peek() byte {
+	p.skipSpaces()
+	if p.pos == len(p.s) {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func (p *specParser) ident() (string, error) {
+	p.skipSpaces()
+	start := p.pos
+	for p.pos < len(p.s) && isIdentByte(p.s[p.pos]) {
+		p.pos++
+	}
+	if start == p.pos {
+		return "", p.errorf("expect a name")
+	}
+	return strings.ToLower(p.s[start:p.pos]), nil
+}
+
+func (p *specParser) number() (float64, error) {
+	p.skipSpaces()
+	start := p.pos
+	for p.pos < len(p.s) && strings.IndexByte("+-.eE0123456789", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+	v, err := strconv.ParseFloat(p.s[start:p.pos], 64)
+	if err != nil {
+		p.pos = start
+		return 0, p.errorf("expect a number")
+	}
+	return v, nil
+}
+
+func isNumberStart(c byte) bool {
+	return c == '+' || c == '-' || c == '.' || c >= '0' && c <= '9'
+}
+
+func (p *specParser) spec() (*spec, error) {
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	s := &spec{name: name}
+	if p.peek() != '(' {
+		return s, nil
+	}
+	p.pos++
+	if p.peek() == ')' {
+		p.pos++
+		return s, nil
+	}
+
+	for {
+		arg, err := p.arg()
+		if err != nil {
+			return nil, err
+		}
+		s.args = append(s.args, arg)
+
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ')':
+			p.pos++
+			return s, nil
+		default:
+			return nil, p.errorf("expect ',' or ')'")
+		}
+	}
+}
+
+func (p *specParser) arg() (specArg, error) {
+	var arg specArg
+	if isNumberStart(p.peek()) {
+		v, err := p.number()
+		if err != nil {
+			return arg, err
+		}
+		if p.peek() != ':' {
+			arg.number = v
+			return arg, nil
+		}
+		p.pos++
+		arg.weight, arg.hasWeight = v, true
+		arg.spec, err = p.spec()
+		return arg, err
+	}
+
+	start := p.pos
+	name, err := p.ident()
+	if err != nil {
+		return arg, err
+	}
+	if p.peek() != '=' {
+		// a nested spec
+		p.pos = start
+		arg.spec, err = p.spec()
+		return arg, err
+	}
+	p.pos++
+	arg.name = name
+	arg.number, err = p.number()
+	return arg, err
+}
+
+// specArgs gives access to the arguments of a distribution by position or name.
+type specArgs struct {
+	s    *spec
+	used []bool
+}
+
+func (a *specArgs) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("distribution %s: %s", a.s.name, fmt.Sprintf(format, args...))
+}
+
+// number returns the number argument at pos or with name, or def if there
+// is none. pos is -1 for named only arguments.
+func (a *specArgs) number(pos int, name string, def float64) (float64, bool, error) {
+	for i, arg := range a.s.args {
+		if arg.name == name {
+			a.used[i] = true
+			return arg.number, true, nil
+		}
+	}
+	if pos < 0 || pos >= len(a.s.args) {
+		return def, false, nil
+	}
+	arg := a.s.args[pos]
+	if arg.name != "" {
+		return def, false, nil
+	}
+	if arg.spec != nil {
+		return 0, false, a.errorf("argument %d must be a number", pos+1)
+	}
+	a.used[pos] = true
+	return arg.number, true, nil
+}
+
+func (a *specArgs) int(pos int, name string, def int64) (int64, error) {
+	v, _, err := a.number(pos, name, float64(def))
+	return int64(v), err
+}
+
+func (a *specArgs) float(pos int, name string, def float64) (float64, error) {
+	v, _, err := a.number(pos, name, def)
+	return v, err
+}
+
+func (a *specArgs) spec(pos int) (*spec, error) {
+	if pos >= len(a.s.args) || a.s.args[pos].spec == nil || a.s.args[pos].hasWeight {
+		return nil, a.errorf("argument %d must be a distribution", pos+1)
+	}
+	a.used[pos] = true
+	return a.s.args[pos].spec, nil
+}
+
+// check returns an error if an argument is not used.
+func (a *specArgs) check() error {
+	for i, used := range a.used {
+		if used {
+			continue
+		}
+		if name := a.s.args[i].name; name != "" {
+			return a.errorf("unknown argument %s", name)
+		}
+		return a.errorf("unexpected argument %d", i+1)
+	}
+	return nil
+}
+
+// ParseSpec parses a distribution spec into a generator of integers in [lb, ub].
+func ParseSpec(s string, lb int64, ub int64) (ycsb.Generator, error) {
+	p := &specParser{s: s}
+	root, err := p.spec()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != 0 {
+		return nil, p.errorf("unexpected %q", p.s[p.pos:])
+	}
+	return buildSpec(root, lb, ub)
+}
+
+func buildSpec(s *spec, lb int64, ub int64) (ycsb.Generator, error) {
+	a := &specArgs{s: s, used: make([]bool, len(s.args))}
+	gen, err := buildGenerator(a, lb, ub)
+	if err != nil {
+		return nil, err
+	}
+	if err = a.check(); err != nil {
+		return nil, err
+	}
+	return gen, nil
+}
+
+func buildGenerator(a *specArgs, lb int64, ub int64) (ycsb.Generator, error) {
+	switch a.s.name {
+	case "uniform", "sequential":
+		min, err := a.int(0, "min", lb)
+		if err != nil {
+			return nil, err
+		}
+		max, err := a.int(1, "max", ub)
+		if err != nil {
+			return nil, err
+		}
+		if min > max {
+			return nil, a.errorf("min %d is bigger than max %d", min, max)
+		}
+		if a.s.name == "uniform" {
+			return NewUniform(min, max), nil
+		}
+		return NewSequential(min, max), nil
+	case "constant":
+		value, ok, err := a.number(0, "value", 0)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, a.errorf("needs a value")
+		}
+		return NewConstant(int64(value)), nil
+	case "zipfian":
+		min, err := a.int(0, "min", lb)
+		if err != nil {
+			return nil, err
+		}
+		max, err := a.int(1, "max", ub)
+		if err != nil {
+			return nil, err
+		}
+		if min > max {
+			return nil, a.errorf("min %d is bigger than max %d", min, max)
+		}
+		theta, err := a.float(2, "theta", ZipfianConstant)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case theta <= 0 || theta == 1:
+			return nil, a.errorf("theta must be positive and not 1, but got %v", theta)
+		case theta < 1:
+			return NewZipfianWithRange(min, max, theta), nil
+		default:
+			return NewZipf(min, max, theta), nil
+		}
+	case "hotspot", "movinghotspot", "jumpinghotspot":
+		data, err := a.float(0, "data", 0.2)
+		if err != nil {
+			return nil, err
+		}
+		ops, err := a.float(1, "ops", 0.8)
+		if err != nil {
+			return nil, err
+		}
+		switch a.s.name {
+		case "movinghotspot":
+			speed, err := a.float(2, "speed", 100)
+			if err != nil {
+				return nil, err
+			}
+			return NewMovingHotspot(lb, ub, data, ops, speed), nil
+		case "jumpinghotspot":
+			period, err := a.float(2, "period", 10)
+			if err != nil {
+				return nil, err
+			}
+			return NewJumpingHotspot(lb, ub, data, ops, time.Duration(period*float64(time.Second))), nil
+		default:
+			return NewHotspot(lb, ub, data, ops), nil
+		}
+	case "normal":
+		mu, err := a.float(0, "mu", float64(ub-lb)/2)
+		if err != nil {
+			return nil, err
+		}
+		sigma, err := a.float(1, "sigma", float64(ub-lb+1)/6)
+		if err != nil {
+			return nil, err
+		}
+		speed, err := a.float(2, "speed", 0)
+		if err != nil {
+			return nil, err
+		}
+		return NewNormal(lb, ub, mu, sigma, time.Duration(speed*float64(time.Millisecond))), nil
+	case "exponential":
+		if mean, ok, _ := a.number(-1, "mean", 0); ok {
+			if mean <= 0 {
+				return nil, a.errorf("mean must be positive, but got %v", mean)
+			}
+			return NewClamp(NewOffset(NewExponentialWithMean(mean), lb), lb, ub), nil
+		}
+		percentile, err := a.float(0, "percentile", 95)
+		if err != nil {
+			return nil, err
+		}
+		rng, err := a.float(1, "range", float64(ub-lb+1))
+		if err != nil {
+			return nil, err
+		}
+		return NewClamp(NewOffset(NewExponential(percentile, rng), lb), lb, ub), nil
+	case "mix":
+		if len(a.s.args) == 0 {
+			return nil, a.errorf("needs at least one weight: distribution")
+		}
+		m := NewMix()
+		for i, arg := range a.s.args {
+			if !arg.hasWeight || arg.weight < 0 {
+				return nil, a.errorf("argument %d must be a non-negative weight: distribution", i+1)
+			}
+			gen, err := buildSpec(arg.spec, lb, ub)
+			if err != nil {
+				return nil, err
+			}
+			a.used[i] = true
+			m.Add(arg.weight, gen)
+		}
+		return m, nil
+	case "clamp":
+		inner, err := a.spec(0)
+		if err != nil {
+			return nil, err
+		}
+		min, err := a.int(1, "min", lb)
+		if err != nil {
+			return nil, err
+		}
+		max, err := a.int(2, "max", ub)
+		if err != nil {
+			return nil, err
+		}
+		if min > max {
+			return nil, a.errorf("min %d is bigger than max %d", min, max)
+		}
+		gen, err := buildSpec(inner, min, max)
+		if err != nil {
+			return nil, err
+		}
+		return NewClamp(gen, min, max), nil
+	case "scramble":
+		inner, err := a.spec(0)
+		if err != nil {
+			return nil, err
+		}
+		gen, err := buildSpec(inner, lb, ub)
+		if err != nil {
+			return nil, err
+		}
+		return NewScramble(gen, lb, ub), nil
+	default:
+		return nil, a.errorf("unknown distribution")
+	}
+}
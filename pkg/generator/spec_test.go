@@ -0,0 +1,94 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestParseSpec(t *testing.T) {
+	tests := []struct {
+		spec string
+		min  int64
+		max  int64
+	}{
+		{"uniform", 0, 99},
+		{"uniform(10, 19)", 10, 19},
+		{"uniform(min=10, max=19)", 10, 19},
+		{"constant(7)", 7, 7},
+		{"zipfian", 0, 99},
+		{"zipfian(theta=1.5)", 0, 99},
+		{"zipfian(1, 64)", 1, 64},
+		{"scramble(zipfian(theta=1.2))", 0, 99},
+		{"hotspot(data=0.1, ops=0.9)", 0, 99},
+		{"normal(50, 10)", 0, 99},
+		{"exponential(mean=500)", 0, 99},
+		{"exponential(50, 200)", 0, 99},
+		{" mix( 0.8 : zipfian(theta=1.2), 0.2: uniform ) ", 0, 99},
+		{"clamp(exponential(mean=50), 1, 20)", 1, 20},
+		{"clamp(mix(1: constant(-5), 1e0: constant(500)), 0, 99)", 0, 99},
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for _, test := range tests {
+		gen, err := ParseSpec(test.spec, 0, 99)
+		if err != nil {
+			t.Fatalf("%s: %v", test.spec, err)
+		}
+		for i := 0; i < 1000; i++ {
+			if v := gen.Next(r); v < test.min || v > test.max {
+				t.Fatalf("%s: got %d out of [%d, %d]", test.spec, v, test.min, test.max)
+			}
+		}
+	}
+}
+
+func TestParseSpecSkew(t *testing.T) {
+	gen, err := ParseSpec("mix(0.9: constant(0), 0.1: uniform(1, 99))", 0, 99)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := rand.New(rand.NewSource(1))
+	zeros := 0
+	for i := 0; i < 10000; i++ {
+		if gen.Next(r) == 0 {
+			zeros++
+		}
+	}
+	if zeros < 8500 || zeros > 9500 {
+		t.Fatalf("got %d zeros out of 10000, want about 9000", zeros)
+	}
+}
+
+func TestParseSpecError(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"unknown",
+		"uniform(1, 2, 3)",
+		"uniform(5, 1)",
+		"uniform(foo=1)",
+		"zipfian(theta=1)",
+		"constant",
+		"mix(uniform)",
+		"mix()",
+		"clamp(1, 2, 3)",
+		"uniform(1",
+		"uniform) x",
+	} {
+		if _, err := ParseSpec(spec, 0, 99); err == nil {
+			t.Errorf("%q: expect an error", spec)
+		}
+	}
+}
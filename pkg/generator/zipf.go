@@ -0,0 +1,80 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Zipf generates a zipfian distribution with an exponent above 1, which the
+// algorithm of Zipfian doesn't support. Item i is chosen with a probability
+// proportional to 1/(i+1)^theta, min being the most popular one.
+//
+// The algorithm is the rejection-inversion of "Rejection-inversion to generate
+// variates from monotone discrete distributions", W.Hormann, G.Derflinger, 1996,
+// like rand.Zipf, which can't share one generator between goroutines.
+type Zipf struct {
+	Number
+	min          int64
+	imax         float64
+	q            float64
+	oneminusQ    float64
+	oneminusQinv float64
+	hxm          float64
+	hx0minusHxm  float64
+	s            float64
+}
+
+func (z *Zipf) h(x float64) float64 {
+	return math.Exp(z.oneminusQ*math.Log(1+x)) * z.oneminusQinv
+}
+
+func (z *Zipf) hinv(x float64) float64 {
+	return math.Exp(z.oneminusQinv*math.Log(z.oneminusQ*x)) - 1
+}
+
+// NewZipf creates a Zipf generator over [min, max], theta must be above 1.
+func NewZipf(min int64, max int64, theta float64) *Zipf {
+	z := &Zipf{
+		min:          min,
+		imax:         float64(max - min),
+		q:            theta,
+		oneminusQ:    1 - theta,
+		oneminusQinv: 1 / (1 - theta),
+	}
+	z.hxm = z.h(z.imax + 0.5)
+	z.hx0minusHxm = z.h(0.5) - 1 - z.hxm
+	z.s = 1 - z.hinv(z.h(1.5)-math.Exp(-z.q*math.Log(2)))
+	return z
+}
+
+// Next implements the Generator Next interface.
+func (z *Zipf) Next(r *rand.Rand) int64 {
+	var k float64
+	for {
+		ur := z.hxm + r.Float64()*z.hx0minusHxm
+		x := z.hinv(ur)
+		k = math.Floor(x + 0.5)
+		if k-x <= z.s {
+			break
+		}
+		if ur >= z.h(k+0.5)-math.Exp(-math.Log(k+1)*z.q) {
+			break
+		}
+	}
+	value := z.min + int64(k)
+	z.SetLastValue(value)
+	return value
+}
@@ -0,0 +1,295 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linearizability
+
+import (
+	"math"
+	"math/bits"
+	"sort"
+	"time"
+)
+
+// Pending is the return time of a write whose outcome is unknown. Such a
+// write may take effect at any time after its call, or never.
+const Pending = int64(math.MaxInt64)
+
+// maxShrinkOps is the size above which Minimize doesn't try to drop the
+// operations one by one.
+const maxShrinkOps = 200
+
+// Value is the value of a register, identified by its hash.
+type Value struct {
+	Hash    uint64
+	Present bool
+}
+
+// Operation is a read or a write of a single register. Call and Return are
+// the times the operation was invoked and completed.
+type Operation struct {
+	ClientID int
+	Write    bool
+	Value    Value
+	Call     int64
+	Return   int64
+}
+
+// Result is the outcome of a linearizability check.
+type Result int
+
+// The results of a check.
+const (
+	Ok Result = iota
+	Illegal
+	Unknown
+)
+
+func (r Result) String() string {
+	switch r {
+	case Ok:
+		return "linearizable"
+	case Illegal:
+		return "not linearizable"
+	default:
+		return "unknown"
+	}
+}
+
+// registerState is the state of the register model. The initial value of a
+// register is unknown, the first read or write defines it.
+type registerState struct {
+	known bool
+	value Value
+}
+
+func step(s registerState, op *Operation) (bool, registerState) {
+	if op.Write || !s.known {
+		return true, registerState{known: true, value: op.Value}
+	}
+	return s.value == op.Value, s
+}
+
+type bitset []uint64
+
+func newBitset(n int) bitset {
+	return make(bitset, (n+63)/64)
+}
+
+func (b bitset) set(i int) {
+	b[i/64] |= 1 << uint(i%64)
+}
+
+func (b bitset) clear(i int) {
+	b[i/64] &^= 1 << uint(i%64)
+}
+
+func (b bitset) clone() bitset {
+	c := make(bitset, len(b))
+	copy(c, b)
+	return c
+}
+
+func (b bitset) equals(c bitset) bool {
+	for i := range b {
+		if b[i] != c[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (b bitset) hash() uint64 {
+	h := uint64(len(b))
+	for _, v := range b {
+		h = bits.RotateLeft64(h, 7) ^ v
+	}
+	return h
+}
+
+// node is an entry of the doubly linked list of call and return events.
+type node struct {
+	id int
+	// match is the return node of a call node, nil for a return node.
+	match *node
+	prev  *node
+	next  *node
+}
+
+func (n *node) lift() {
+	n.prev.next = n.next
+	n.next.prev = n.prev
+	m := n.match
+	m.prev.next = m.next
+	if m.next != nil {
+		m.next.prev = m.prev
+	}
+}
+
+func (n *node) unlift() {
+	m := n.match
+	m.prev.next = m
+	if m.next != nil {
+		m.next.prev = m
+	}
+	n.prev.next = n
+	n.next.prev = n
+}
+
+type event struct {
+	id   int
+	call bool
+	time int64
+}
+
+// makeList returns the sentinel head of the events of ops ordered by time.
+// A call precedes a return at the same time, so they count as concurrent.
+func makeList(ops []Operation) *node {
+	events := make([]event, 0, 2*len(ops))
+	for i, op := range ops {
+		events = append(events, event{id: i, call: true, time: op.Call}, event{id: i, time: op.Return})
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].time != events[j].time {
+			return events[i].time < events[j].time
+		}
+		return events[i].call && !events[j].call
+	})
+
+	returns := make([]*node, len(ops))
+	nodes := make([]*node, len(events))
+	for i := len(events) - 1; i >= 0; i-- {
+		n := &node{id: events[i].id}
+		if events[i].call {
+			n.match = returns[n.id]
+		} else {
+			returns[n.id] = n
+		}
+		nodes[i] = n
+	}
+
+	head := &node{id: -1}
+	prev := head
+	for _, n := range nodes {
+		prev.next = n
+		n.prev = prev
+		prev = n
+	}
+	return head
+}
+
+type cacheEntry struct {
+	linearized bitset
+	state      registerState
+}
+
+type callFrame struct {
+	n     *node
+	state registerState
+}
+
+// Check reports whether the history of a single register is linearizable,
+// or Unknown if it can't tell before the deadline. It searches for a
+// linearization like Porcupine does, the algorithm of Wing & Gong with the
+// memoization of Lowe.
+func Check(ops []Operation, deadline time.Time) Result {
+	head := makeList(ops)
+	linearized := newBitset(len(ops))
+	cache := make(map[uint64][]cacheEntry)
+	var calls []callFrame
+	var state registerState
+
+	seen := func(linearized bitset, state registerState) bool {
+		for _, e := range cache[linearized.hash()] {
+			if e.state == state && e.linearized.equals(linearized) {
+				return true
+			}
+		}
+		return false
+	}
+
+	n := head.next
+	for steps := 0; head.next != nil; steps++ {
+		if steps%1024 == 0 && !deadline.IsZero() && time.Now().After(deadline) {
+			return Unknown
+		}
+
+		if n.match == nil {
+			// A return before its call is linearized, backtrack.
+			if len(calls) == 0 {
+				return Illegal
+			}
+			top := calls[len(calls)-1]
+			calls = calls[:len(calls)-1]
+			state = top.state
+			linearized.clear(top.n.id)
+			top.n.unlift()
+			n = top.n.next
+			continue
+		}
+
+		ok, newState := step(state, &ops[n.id])
+		if ok {
+			newLinearized := linearized.clone()
+			newLinearized.set(n.id)
+			if !seen(newLinearized, newState) {
+				h := newLinearized.hash()
+				cache[h] = append(cache[h], cacheEntry{linearized: newLinearized, state: newState})
+				calls = append(calls, callFrame{n: n, state: state})
+				state = newState
+				linearized.set(n.id)
+				n.lift()
+				n = head.next
+				continue
+			}
+		}
+		n = n.next
+	}
+	return Ok
+}
+
+// Minimize returns a small sub-history of the non-linearizable ops which is
+// still not linearizable: the shortest failing prefix in the call order,
+// shrunk by dropping every operation not needed for the violation.
+func Minimize(ops []Operation, deadline time.Time) []Operation {
+	sorted := make([]Operation, len(ops))
+	copy(sorted, ops)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Call < sorted[j].Call
+	})
+
+	// sorted[:hi] is known to be illegal, the search may not find the
+	// shortest one since a prefix may lose the write a read depends on.
+	lo, hi := 0, len(sorted)
+	for lo+1 < hi {
+		mid := (lo + hi) / 2
+		if Check(sorted[:mid], deadline) == Illegal {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	sorted = sorted[:hi]
+	if len(sorted) > maxShrinkOps {
+		return sorted
+	}
+
+	for i := len(sorted) - 1; i >= 0; i-- {
+		candidate := make([]Operation, 0, len(sorted)-1)
+		candidate = append(candidate, sorted[:i]...)
+		candidate = append(candidate, sorted[i+1:]...)
+		if Check(candidate, deadline) == Illegal {
+			sorted = candidate
+		}
+	}
+	return sorted
+}
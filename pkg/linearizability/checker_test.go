@@ -0,0 +1,77 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linearizability
+
+import (
+	"testing"
+	"time"
+)
+
+func write(v uint64, call int64, ret int64) Operation {
+	return Operation{Write: true, Value: Value{Hash: v, Present: true}, Call: call, Return: ret}
+}
+
+func read(v uint64, call int64, ret int64) Operation {
+	return Operation{Value: Value{Hash: v, Present: true}, Call: call, Return: ret}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name string
+		ops  []Operation
+		want Result
+	}{
+		{"empty", nil, Ok},
+		{"sequential", []Operation{write(1, 0, 10), read(1, 20, 30), write(2, 40, 50), read(2, 60, 70)}, Ok},
+		{"stale read", []Operation{write(1, 0, 10), write(2, 20, 30), read(1, 40, 50)}, Illegal},
+		{"concurrent read", []Operation{write(1, 0, 10), write(2, 20, 50), read(1, 30, 40), read(2, 45, 60)}, Ok},
+		{"new then old", []Operation{write(1, 0, 10), write(2, 20, 100), read(2, 30, 40), read(1, 50, 60)}, Illegal},
+		{"unknown initial value", []Operation{read(7, 0, 10), read(7, 20, 30), write(1, 40, 50)}, Ok},
+		{"unknown initial value changed", []Operation{read(7, 0, 10), read(8, 20, 30)}, Illegal},
+		{"pending write applied", []Operation{write(1, 0, 10), write(2, 20, Pending), read(2, 30, 40)}, Ok},
+		{"pending write not applied", []Operation{write(1, 0, 10), write(2, 20, Pending), read(1, 30, 40)}, Ok},
+		{"absent after delete", []Operation{
+			write(1, 0, 10),
+			{Write: true, Call: 20, Return: 30},
+			{Call: 40, Return: 50},
+		}, Ok},
+	}
+
+	for _, tt := range tests {
+		if got := Check(tt.ops, time.Time{}); got != tt.want {
+			t.Errorf("%s: want %s, but got %s", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestMinimize(t *testing.T) {
+	var ops []Operation
+	for i := int64(0); i < 50; i++ {
+		ops = append(ops, write(uint64(i), i*100, i*100+10), read(uint64(i), i*100+20, i*100+30))
+	}
+	// A stale read in the middle of the history.
+	ops = append(ops, read(3, 2040, 2050))
+
+	if got := Check(ops, time.Time{}); got != Illegal {
+		t.Fatalf("want %s, but got %s", Illegal, got)
+	}
+
+	min := Minimize(ops, time.Time{})
+	if got := Check(min, time.Time{}); got != Illegal {
+		t.Fatalf("minimized history: want %s, but got %s", Illegal, got)
+	}
+	if len(min) != 2 {
+		t.Fatalf("want 2 operations, but got %v", min)
+	}
+}
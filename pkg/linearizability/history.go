@@ -0,0 +1,283 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linearizability
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+)
+
+const shardCount = 64
+
+// maxReported is the number of violating registers whose sub-history is printed.
+const maxReported = 5
+
+type recordKey struct {
+	table string
+	key   string
+}
+
+// keyHistory holds the operations on the fields of a record. The deletes
+// write every field of the record, including the ones not seen yet, so
+// they are expanded into the field histories when checking.
+type keyHistory struct {
+	fields  map[string][]Operation
+	deletes []Operation
+}
+
+type shard struct {
+	sync.Mutex
+	keys map[recordKey]*keyHistory
+}
+
+// history records the operations of the run. Every field of a record is a
+// register, and linearizability is a local property, so every register can
+// be checked on its own.
+type history struct {
+	start   time.Time
+	timeout time.Duration
+	shards  [shardCount]shard
+}
+
+var globalHistory *history
+
+// Init initializes the global history if the linearizability check is
+// enabled for the run phase, and discards the previous one.
+func Init(p *properties.Properties) {
+	if !p.GetBool(prop.LinearizabilityCheck, prop.LinearizabilityCheckDefault) || !p.GetBool(prop.DoTransactions, true) {
+		globalHistory = nil
+		return
+	}
+
+	h := &history{
+		start:   time.Now(),
+		timeout: p.GetParsedDuration(prop.LinearizabilityTimeout, time.Minute),
+	}
+	for i := range h.shards {
+		h.shards[i].keys = make(map[recordKey]*keyHistory)
+	}
+	globalHistory = h
+}
+
+// IsEnabled returns whether the operations are recorded.
+func IsEnabled() bool {
+	return globalHistory != nil
+}
+
+// Now returns the time to pass as the call time of an operation.
+func Now() int64 {
+	return int64(time.Since(globalHistory.start))
+}
+
+func valueOf(v []byte, ok bool) Value {
+	if !ok {
+		return Value{}
+	}
+	return Value{Hash: uint64(util.BytesHash64(v)), Present: true}
+}
+
+func (h *history) keyHistory(table string, key string) (*shard, *keyHistory) {
+	s := &h.shards[uint64(util.StringHash64(key))%shardCount]
+	s.Lock()
+	k := recordKey{table: table, key: key}
+	kh, ok := s.keys[k]
+	if !ok {
+		kh = &keyHistory{fields: make(map[string][]Operation)}
+		s.keys[k] = kh
+	}
+	return s, kh
+}
+
+// RecordRead records a read of the fields of a record which started at call.
+// Failed reads don't tell anything and are dropped. The fields missing in
+// values are recorded as absent, unless all the fields were read.
+func RecordRead(clientID int, table string, key string, fields []string, values map[string][]byte, call int64, ok bool) {
+	if !ok {
+		return
+	}
+	ret := Now()
+	s, kh := globalHistory.keyHistory(table, key)
+	defer s.Unlock()
+
+	if len(fields) == 0 {
+		for field, v := range values {
+			kh.fields[field] = append(kh.fields[field], Operation{ClientID: clientID, Value: valueOf(v, true), Call: call, Return: ret})
+		}
+		return
+	}
+	for _, field := range fields {
+		v, present := values[field]
+		kh.fields[field] = append(kh.fields[field], Operation{ClientID: clientID, Value: valueOf(v, present), Call: call, Return: ret})
+	}
+}
+
+// RecordWrite records a write of the fields of a record which started at
+// call. A write which failed or may not have been applied is pending, it
+// may or may not take effect.
+func RecordWrite(clientID int, table string, key string, values map[string][]byte, call int64, ok bool) {
+	ret := Pending
+	if ok {
+		ret = Now()
+	}
+	s, kh := globalHistory.keyHistory(table, key)
+	defer s.Unlock()
+
+	for field, v := range values {
+		kh.fields[field] = append(kh.fields[field], Operation{ClientID: clientID, Write: true, Value: valueOf(v, true), Call: call, Return: ret})
+	}
+}
+
+// RecordDelete records a delete of a record which started at call.
+func RecordDelete(clientID int, table string, key string, call int64, ok bool) {
+	ret := Pending
+	if ok {
+		ret = Now()
+	}
+	s, kh := globalHistory.keyHistory(table, key)
+	defer s.Unlock()
+
+	kh.deletes = append(kh.deletes, Operation{ClientID: clientID, Write: true, Call: call, Return: ret})
+}
+
+type register struct {
+	name string
+	ops  []Operation
+}
+
+type registerResult struct {
+	register
+	result Result
+}
+
+func (h *history) registers() []register {
+	var registers []register
+	for i := range h.shards {
+		s := &h.shards[i]
+		s.Lock()
+		for k, kh := range s.keys {
+			for field, ops := range kh.fields {
+				all := make([]Operation, 0, len(ops)+len(kh.deletes))
+				all = append(all, ops...)
+				all = append(all, kh.deletes...)
+				registers = append(registers, register{
+					name: fmt.Sprintf("%s/%s/%s", k.table, k.key, field),
+					ops:  all,
+				})
+			}
+		}
+		s.Unlock()
+	}
+	sort.Slice(registers, func(i, j int) bool {
+		return registers[i].name < registers[j].name
+	})
+	return registers
+}
+
+func (h *history) formatTime(t int64) string {
+	if t == Pending {
+		return "pending"
+	}
+	return time.Duration(t).String()
+}
+
+func (h *history) outputOperation(w io.Writer, op Operation) {
+	kind := "read"
+	if op.Write {
+		kind = "write"
+	}
+	value := "<absent>"
+	if op.Value.Present {
+		value = fmt.Sprintf("%016x", op.Value.Hash)
+	}
+	fmt.Fprintf(w, "  client %-4d %-5s %-16s call %-14s return %s\n",
+		op.ClientID, kind, value, h.formatTime(op.Call), h.formatTime(op.Return))
+}
+
+// deadlineAfter returns the deadline of a check, zero for no time limit.
+func deadlineAfter(timeout time.Duration) time.Time {
+	if timeout <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(timeout)
+}
+
+// Output checks the recorded history, writes the results to w and returns
+// whether no violation was found. It does nothing if the check is disabled.
+func Output(w io.Writer) bool {
+	h := globalHistory
+	if h == nil {
+		return true
+	}
+
+	registers := h.registers()
+	deadline := deadlineAfter(h.timeout)
+
+	results := make([]registerResult, len(registers))
+	var wg sync.WaitGroup
+	next := make(chan int)
+	for i := 0; i < runtime.GOMAXPROCS(0); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range next {
+				results[j] = registerResult{register: registers[j], result: Check(registers[j].ops, deadline)}
+			}
+		}()
+	}
+	opCount := 0
+	for i := range registers {
+		opCount += len(registers[i].ops)
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	var illegal []registerResult
+	unknown := 0
+	for _, r := range results {
+		switch r.result {
+		case Illegal:
+			illegal = append(illegal, r)
+		case Unknown:
+			unknown++
+		}
+	}
+
+	fmt.Fprintf(w, "Linearizability check: %d registers, %d operations, %d not linearizable, %d unknown\n",
+		len(registers), opCount, len(illegal), unknown)
+	if unknown > 0 {
+		fmt.Fprintf(w, "WARNING: the check of %d registers didn't finish in %s\n", unknown, h.timeout)
+	}
+	for i, r := range illegal {
+		if i == maxReported {
+			fmt.Fprintf(w, "... %d more registers not linearizable\n", len(illegal)-maxReported)
+			break
+		}
+		ops := Minimize(r.ops, deadlineAfter(h.timeout))
+		fmt.Fprintf(w, "Register %s is not linearizable, violating sub-history of %d out of %d operations:\n",
+			r.name, len(ops), len(r.ops))
+		for _, op := range ops {
+			h.outputOperation(w, op)
+		}
+	}
+	return len(illegal) == 0
+}
@@ -0,0 +1,265 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package measurement
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+	"runtime/metrics"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+)
+
+const (
+	gcPausesMetric     = "/gc/pauses:seconds"
+	gcCPUMetric        = "/cpu/classes/gc/total:cpu-seconds"
+	schedLatencyMetric = "/sched/latencies:seconds"
+	clientStatsOpName  = "CLIENT"
+	clientStatsTotalOp = "CLIENT_TOTAL"
+)
+
+var clientStatsHeader = []string{"Client", "CPU(%)", "GC(%)", "GCPause50th(us)", "GCPause99th(us)", "GCPauseMax(us)",
+	"Heap(MB)", "Goroutines", "SchedLat50th(us)", "SchedLat99th(us)"}
+
+// clientSample is a snapshot of the resource usage of the go-ycsb process.
+type clientSample struct {
+	at           time.Time
+	cpu          time.Duration
+	cpuOK        bool
+	gcPauseTotal uint64
+	// gcCPU is the CPU time spent in the GC, in seconds, if the runtime
+	// reports it.
+	gcCPU        float64
+	gcCPUOK      bool
+	heapAlloc    uint64
+	gcPauses     *metrics.Float64Histogram
+	schedLatency *metrics.Float64Histogram
+}
+
+// clientStats is the resource usage of the go-ycsb process between two samples.
+type clientStats struct {
+	elapsed    time.Duration
+	cpuPercent float64
+	cpuOK      bool
+	// gcPercent is the share of the CPU of GOMAXPROCS used by the GC, or of
+	// the time the GC paused the client if the runtime doesn't report it.
+	gcPercent  float64
+	gcPause50  time.Duration
+	gcPause99  time.Duration
+	gcPauseMax time.Duration
+	heapMB     float64
+	goroutines int
+	schedLat50 time.Duration
+	schedLat99 time.Duration
+}
+
+// clientMonitor samples the CPU, GC, heap and scheduler statistics of the
+// benchmark client itself, so a saturated client is not mistaken for a slow
+// database.
+type clientMonitor struct {
+	p *properties.Properties
+
+	gcThreshold  float64
+	cpuThreshold float64
+
+	start *clientSample
+	last  *clientSample
+}
+
+func newClientMonitor(p *properties.Properties) *clientMonitor {
+	m := &clientMonitor{
+		p:            p,
+		gcThreshold:  p.GetFloat64(prop.ClientStatsGCThreshold, prop.ClientStatsGCThresholdDefault),
+		cpuThreshold: p.GetFloat64(prop.ClientStatsCPUThreshold, prop.ClientStatsCPUThresholdDefault),
+	}
+	m.start = takeClientSample()
+	m.last = m.start
+	return m
+}
+
+func takeClientSample() *clientSample {
+	s := &clientSample{at: time.Now()}
+	s.cpu, s.cpuOK = processCPUTime()
+
+	samples := []metrics.Sample{{Name: gcPausesMetric}, {Name: schedLatencyMetric}, {Name: gcCPUMetric}}
+	metrics.Read(samples)
+	if samples[0].Value.Kind() == metrics.KindFloat64Histogram {
+		s.gcPauses = samples[0].Value.Float64Histogram()
+	}
+	if samples[1].Value.Kind() == metrics.KindFloat64Histogram {
+		s.schedLatency = samples[1].Value.Float64Histogram()
+	}
+	if samples[2].Value.Kind() == metrics.KindFloat64 {
+		s.gcCPU, s.gcCPUOK = samples[2].Value.Float64(), true
+	}
+
+	// ReadMemStats stops the world, it is read once per sample.
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	s.gcPauseTotal = ms.PauseTotalNs
+	s.heapAlloc = ms.HeapAlloc
+	return s
+}
+
+// stats computes the client statistics between the from sample and now.
+func (m *clientMonitor) stats(from *clientSample, now *clientSample) clientStats {
+	elapsed := now.at.Sub(from.at)
+	st := clientStats{
+		elapsed:    elapsed,
+		heapMB:     float64(now.heapAlloc) / (1 << 20),
+		goroutines: runtime.NumGoroutine(),
+	}
+	if elapsed <= 0 {
+		return st
+	}
+
+	procs := float64(runtime.GOMAXPROCS(0))
+	if from.cpuOK && now.cpuOK {
+		st.cpuOK = true
+		st.cpuPercent = float64(now.cpu-from.cpu) / float64(elapsed) / procs * 100
+	}
+	if from.gcCPUOK && now.gcCPUOK {
+		st.gcPercent = (now.gcCPU - from.gcCPU) / elapsed.Seconds() / procs * 100
+	} else {
+		st.gcPercent = float64(now.gcPauseTotal-from.gcPauseTotal) / float64(elapsed) * 100
+	}
+
+	gcPauses := histogramDelta(from.gcPauses, now.gcPauses)
+	st.gcPause50 = histogramQuantile(gcPauses, 0.5)
+	st.gcPause99 = histogramQuantile(gcPauses, 0.99)
+	st.gcPauseMax = histogramQuantile(gcPauses, 1)
+
+	schedLatency := histogramDelta(from.schedLatency, now.schedLatency)
+	st.schedLat50 = histogramQuantile(schedLatency, 0.5)
+	st.schedLat99 = histogramQuantile(schedLatency, 0.99)
+	return st
+}
+
+// interval returns the client statistics since the previous interval.
+func (m *clientMonitor) interval() clientStats {
+	now := takeClientSample()
+	st := m.stats(m.last, now)
+	m.last = now
+	return st
+}
+
+// total returns the client statistics since the monitor was created.
+func (m *clientMonitor) total() clientStats {
+	return m.stats(m.start, takeClientSample())
+}
+
+func (m *clientMonitor) output(w io.Writer, name string, st clientStats) {
+	cpu := "n/a"
+	if st.cpuOK {
+		cpu = util.FloatToOneString(st.cpuPercent)
+	}
+	line := []string{
+		name,
+		cpu,
+		util.FloatToOneString(st.gcPercent),
+		util.IntToString(st.gcPause50.Microseconds()),
+		util.IntToString(st.gcPause99.Microseconds()),
+		util.IntToString(st.gcPauseMax.Microseconds()),
+		util.FloatToOneString(st.heapMB),
+		util.IntToString(st.goroutines),
+		util.IntToString(st.schedLat50.Microseconds()),
+		util.IntToString(st.schedLat99.Microseconds()),
+	}
+
+	outputStyle := m.p.GetString(prop.OutputStyle, util.OutputStylePlain)
+	switch outputStyle {
+	case util.OutputStylePlain:
+		util.RenderString(w, "%-6s - %s\n", clientStatsHeader, [][]string{line})
+	case util.OutputStyleJson:
+		util.RenderJson(w, clientStatsHeader, [][]string{line})
+	case util.OutputStyleTable:
+		util.RenderTable(w, clientStatsHeader, [][]string{line})
+	default:
+		panic("unsupported outputstyle: " + outputStyle)
+	}
+
+	for _, warning := range m.warnings(st) {
+		fmt.Fprintf(w, "WARNING: %s, the results may be bounded by the go-ycsb client rather than the database\n", warning)
+	}
+}
+
+// warnings returns the reasons why the client looks saturated.
+func (m *clientMonitor) warnings(st clientStats) []string {
+	var warnings []string
+	if st.cpuOK && st.cpuPercent >= m.cpuThreshold {
+		warnings = append(warnings, fmt.Sprintf("client CPU usage is %.1f%% of GOMAXPROCS=%d",
+			st.cpuPercent, runtime.GOMAXPROCS(0)))
+	}
+	if st.gcPercent >= m.gcThreshold {
+		warnings = append(warnings, fmt.Sprintf("client GC takes %.1f%% of the time", st.gcPercent))
+	}
+	return warnings
+}
+
+// histogramDelta returns the observations recorded in to but not in from.
+func histogramDelta(from, to *metrics.Float64Histogram) *metrics.Float64Histogram {
+	if to == nil {
+		return nil
+	}
+	if from == nil || len(from.Counts) != len(to.Counts) {
+		return to
+	}
+	delta := &metrics.Float64Histogram{
+		Counts:  make([]uint64, len(to.Counts)),
+		Buckets: to.Buckets,
+	}
+	for i := range to.Counts {
+		delta.Counts[i] = to.Counts[i] - from.Counts[i]
+	}
+	return delta
+}
+
+// histogramQuantile returns the upper bound of the bucket holding the quantile q.
+func histogramQuantile(h *metrics.Float64Histogram, q float64) time.Duration {
+	if h == nil {
+		return 0
+	}
+	var total uint64
+	for _, c := range h.Counts {
+		total += c
+	}
+	if total == 0 {
+		return 0
+	}
+
+	threshold := uint64(math.Ceil(q * float64(total)))
+	if threshold == 0 {
+		threshold = 1
+	}
+	var seen uint64
+	for i, c := range h.Counts {
+		seen += c
+		if seen < threshold {
+			continue
+		}
+		// Buckets has len(Counts)+1 boundaries, fall back to the lower bound
+		// if the upper one is +Inf.
+		bound := h.Buckets[i+1]
+		if math.IsInf(bound, 1) {
+			bound = h.Buckets[i]
+		}
+		return time.Duration(bound * float64(time.Second))
+	}
+	return 0
+}
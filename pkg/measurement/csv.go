@@ -3,7 +3,11 @@ package measurement
 import (
 	"fmt"
 	"io"
+	"sort"
 	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
 )
 
 type csventry struct {
@@ -50,6 +54,27 @@ func (c *csvs) Summary() {
 	// do nothing as csvs don't keep a summary
 }
 
-func (c *csvs) ExportLatency(w io.Writer) {
-
+func (c *csvs) Results() []ycsb.OpResult {
+	results := make([]ycsb.OpResult, 0, len(c.opCsv))
+	for op, entries := range c.opCsv {
+		hist := hdrhistogram.New(1, int64(24*time.Hour/time.Microsecond), 3)
+		var first, last int64
+		for i, entry := range entries {
+			hist.RecordValue(entry.latencyUs)
+			if i == 0 || entry.startUs < first {
+				first = entry.startUs
+			}
+			if end := entry.startUs + entry.latencyUs; end > last {
+				last = end
+			}
+		}
+		results = append(results, ycsb.OpResult{
+			Op:      op,
+			Elapsed: time.Duration(last-first) * time.Microsecond,
+			Hist:    hist,
+			Unit:    time.Microsecond,
+		})
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Op < results[j].Op })
+	return results
 }
@@ -0,0 +1,330 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package measurement
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// properties
+const (
+	cdfResolution           = "exporter.cdf.resolution"
+	cdfResolutionDefault    = float64(0.01)
+	prometheusPrefix        = "exporter.prometheus.prefix"
+	prometheusPrefixDefault = "ycsb"
+	influxMeasurement       = "exporter.influx.measurement"
+	influxMeasurementDef    = "ycsb"
+)
+
+func unitName(unit time.Duration) string {
+	switch unit {
+	case time.Nanosecond:
+		return "ns"
+	case time.Millisecond:
+		return "ms"
+	default:
+		return "us"
+	}
+}
+
+func formatPercentile(per float64) string {
+	return strconv.FormatFloat(per, 'f', -1, 64)
+}
+
+func opsPerSecond(r ycsb.OpResult) float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Hist.TotalCount()) / r.Elapsed.Seconds()
+}
+
+// textExporter writes the results in the same format as the plain summary.
+type textExporter struct {
+	percentiles []float64
+}
+
+func (e *textExporter) Export(w io.Writer, results []ycsb.OpResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+	opts := &histogramOptions{percentiles: e.percentiles, unit: results[0].Unit, unitName: unitName(results[0].Unit)}
+	lines := make([][]string, 0, len(results))
+	for _, r := range results {
+		line := []string{
+			r.Op,
+			util.FloatToOneString(r.Elapsed.Seconds()),
+			util.IntToString(r.Hist.TotalCount()),
+			util.FloatToOneString(opsPerSecond(r)),
+			util.IntToString(int64(r.Hist.Mean())),
+			util.IntToString(r.Hist.Min()),
+			util.IntToString(r.Hist.Max()),
+		}
+		for _, per := range e.percentiles {
+			line = append(line, util.IntToString(r.Hist.ValueAtPercentile(per)))
+		}
+		lines = append(lines, line)
+	}
+	util.RenderString(w, "%-6s - %s\n", opts.header(), lines)
+	return nil
+}
+
+type jsonResult struct {
+	Operation   string           `json:"operation"`
+	Elapsed     float64          `json:"elapsed_s"`
+	Count       int64            `json:"count"`
+	OPS         float64          `json:"ops"`
+	Unit        string           `json:"unit"`
+	Avg         float64          `json:"avg"`
+	Min         int64            `json:"min"`
+	Max         int64            `json:"max"`
+	Percentiles map[string]int64 `json:"percentiles"`
+}
+
+// jsonExporter writes the results as a JSON array, one object per operation.
+type jsonExporter struct {
+	percentiles []float64
+}
+
+func (e *jsonExporter) Export(w io.Writer, results []ycsb.OpResult) error {
+	data := make([]jsonResult, 0, len(results))
+	for _, r := range results {
+		res := jsonResult{
+			Operation:   r.Op,
+			Elapsed:     r.Elapsed.Seconds(),
+			Count:       r.Hist.TotalCount(),
+			OPS:         opsPerSecond(r),
+			Unit:        unitName(r.Unit),
+			Avg:         r.Hist.Mean(),
+			Min:         r.Hist.Min(),
+			Max:         r.Hist.Max(),
+			Percentiles: make(map[string]int64, len(e.percentiles)),
+		}
+		for _, per := range e.percentiles {
+			res.Percentiles[formatPercentile(per)] = r.Hist.ValueAtPercentile(per)
+		}
+		data = append(data, res)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(data)
+}
+
+// latencyExporter writes the latency of the total at every percentile from
+// 0.01 to 100, one "percentile latency" line each.
+type latencyExporter struct{}
+
+func (latencyExporter) Export(w io.Writer, results []ycsb.OpResult) error {
+	indexList := make([]float64, 0, 10000)
+	for i := 0.01; i <= 100.0; i += 0.01 {
+		indexList = append(indexList, i)
+	}
+
+	for _, r := range results {
+		if r.Op != "total" {
+			continue
+		}
+		values := r.Hist.ValueAtPercentiles(indexList)
+		for _, q := range indexList {
+			if _, err := fmt.Fprintf(w, "%v %v\n", q, values[q]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// cdfExporter writes the latency CDF of every operation as CSV, stepping the
+// percentile by resolution.
+type cdfExporter struct {
+	resolution float64
+}
+
+func (e *cdfExporter) Export(w io.Writer, results []ycsb.OpResult) error {
+	indexList := make([]float64, 0, int(100/e.resolution)+1)
+	for i := 1; float64(i)*e.resolution <= 100.0; i++ {
+		indexList = append(indexList, float64(i)*e.resolution)
+	}
+
+	for i, r := range results {
+		if i == 0 {
+			if _, err := fmt.Fprintf(w, "operation,percentile,latency_%s\n", unitName(r.Unit)); err != nil {
+				return err
+			}
+		}
+		values := r.Hist.ValueAtPercentiles(indexList)
+		for _, q := range indexList {
+			if _, err := fmt.Fprintf(w, "%s,%s,%d\n", r.Op, strconv.FormatFloat(q, 'f', -1, 64), values[q]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// prometheusExporter writes the results in the Prometheus text exposition
+// format, suitable for the node_exporter textfile collector.
+type prometheusExporter struct {
+	percentiles []float64
+	prefix      string
+	label       string
+}
+
+func (e *prometheusExporter) labels(op string, extra ...string) string {
+	labels := []string{fmt.Sprintf("operation=%q", op)}
+	if e.label != "" {
+		labels = append(labels, fmt.Sprintf("label=%q", e.label))
+	}
+	labels = append(labels, extra...)
+	return "{" + strings.Join(labels, ",") + "}"
+}
+
+func (e *prometheusExporter) Export(w io.Writer, results []ycsb.OpResult) error {
+	b := new(strings.Builder)
+
+	fmt.Fprintf(b, "# HELP %s_operations_total Number of measured operations.\n", e.prefix)
+	fmt.Fprintf(b, "# TYPE %s_operations_total counter\n", e.prefix)
+	for _, r := range results {
+		fmt.Fprintf(b, "%s_operations_total%s %d\n", e.prefix, e.labels(r.Op), r.Hist.TotalCount())
+	}
+
+	fmt.Fprintf(b, "# HELP %s_throughput_ops Operations per second.\n", e.prefix)
+	fmt.Fprintf(b, "# TYPE %s_throughput_ops gauge\n", e.prefix)
+	for _, r := range results {
+		fmt.Fprintf(b, "%s_throughput_ops%s %g\n", e.prefix, e.labels(r.Op), opsPerSecond(r))
+	}
+
+	fmt.Fprintf(b, "# HELP %s_latency_seconds Operation latency.\n", e.prefix)
+	fmt.Fprintf(b, "# TYPE %s_latency_seconds summary\n", e.prefix)
+	for _, r := range results {
+		unit := r.Unit.Seconds()
+		for _, per := range e.percentiles {
+			quantile := fmt.Sprintf("quantile=\"%s\"", strconv.FormatFloat(per/100, 'f', -1, 64))
+			fmt.Fprintf(b, "%s_latency_seconds%s %g\n", e.prefix, e.labels(r.Op, quantile),
+				float64(r.Hist.ValueAtPercentile(per))*unit)
+		}
+		fmt.Fprintf(b, "%s_latency_seconds_sum%s %g\n", e.prefix, e.labels(r.Op),
+			r.Hist.Mean()*float64(r.Hist.TotalCount())*unit)
+		fmt.Fprintf(b, "%s_latency_seconds_count%s %d\n", e.prefix, e.labels(r.Op), r.Hist.TotalCount())
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// influxExporter writes the results in the InfluxDB line protocol, one line
+// per operation.
+type influxExporter struct {
+	percentiles []float64
+	measurement string
+	label       string
+}
+
+func influxEscape(s string) string {
+	return strings.NewReplacer(",", "\\,", "=", "\\=", " ", "\\ ").Replace(s)
+}
+
+func (e *influxExporter) Export(w io.Writer, results []ycsb.OpResult) error {
+	now := time.Now().UnixNano()
+	for _, r := range results {
+		tags := influxEscape(e.measurement) + ",operation=" + influxEscape(r.Op)
+		if e.label != "" {
+			tags += ",label=" + influxEscape(e.label)
+		}
+
+		unit := unitName(r.Unit)
+		fields := []string{
+			fmt.Sprintf("count=%di", r.Hist.TotalCount()),
+			fmt.Sprintf("ops=%g", opsPerSecond(r)),
+			fmt.Sprintf("avg_%s=%g", unit, r.Hist.Mean()),
+			fmt.Sprintf("min_%s=%di", unit, r.Hist.Min()),
+			fmt.Sprintf("max_%s=%di", unit, r.Hist.Max()),
+		}
+		for _, per := range e.percentiles {
+			name := strings.Replace(formatPercentile(per), ".", "_", 1)
+			fields = append(fields, fmt.Sprintf("p%s_%s=%di", name, unit, r.Hist.ValueAtPercentile(per)))
+		}
+
+		if _, err := fmt.Fprintf(w, "%s %s %d\n", tags, strings.Join(fields, ","), now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type textExporterCreator struct{}
+
+func (textExporterCreator) Create(p *properties.Properties) (ycsb.Exporter, error) {
+	return &textExporter{percentiles: newHistogramOptions(p).percentiles}, nil
+}
+
+type jsonExporterCreator struct{}
+
+func (jsonExporterCreator) Create(p *properties.Properties) (ycsb.Exporter, error) {
+	return &jsonExporter{percentiles: newHistogramOptions(p).percentiles}, nil
+}
+
+type latencyExporterCreator struct{}
+
+func (latencyExporterCreator) Create(_ *properties.Properties) (ycsb.Exporter, error) {
+	return latencyExporter{}, nil
+}
+
+type cdfExporterCreator struct{}
+
+func (cdfExporterCreator) Create(p *properties.Properties) (ycsb.Exporter, error) {
+	resolution := p.GetFloat64(cdfResolution, cdfResolutionDefault)
+	if resolution <= 0 || resolution > 100 {
+		return nil, fmt.Errorf("%s must be in (0, 100], but got %v", cdfResolution, resolution)
+	}
+	return &cdfExporter{resolution: resolution}, nil
+}
+
+type prometheusExporterCreator struct{}
+
+func (prometheusExporterCreator) Create(p *properties.Properties) (ycsb.Exporter, error) {
+	return &prometheusExporter{
+		percentiles: newHistogramOptions(p).percentiles,
+		prefix:      p.GetString(prometheusPrefix, prometheusPrefixDefault),
+		label:       p.GetString(prop.Label, ""),
+	}, nil
+}
+
+type influxExporterCreator struct{}
+
+func (influxExporterCreator) Create(p *properties.Properties) (ycsb.Exporter, error) {
+	return &influxExporter{
+		percentiles: newHistogramOptions(p).percentiles,
+		measurement: p.GetString(influxMeasurement, influxMeasurementDef),
+		label:       p.GetString(prop.Label, ""),
+	}, nil
+}
+
+func init() {
+	ycsb.RegisterExporterCreator("text", textExporterCreator{})
+	ycsb.RegisterExporterCreator("json", jsonExporterCreator{})
+	ycsb.RegisterExporterCreator("latency", latencyExporterCreator{})
+	ycsb.RegisterExporterCreator("cdf", cdfExporterCreator{})
+	ycsb.RegisterExporterCreator("prometheus", prometheusExporterCreator{})
+	ycsb.RegisterExporterCreator("influx", influxExporterCreator{})
+}
@@ -15,9 +15,13 @@ package measurement
 
 import (
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
 	"github.com/pingcap/go-ycsb/pkg/util"
 )
 
@@ -25,6 +29,7 @@ type histogram struct {
 	boundCounts util.ConcurrentMap
 	startTime   time.Time
 	hist        *hdrhistogram.Histogram
+	opts        *histogramOptions
 }
 
 // Metric name.
@@ -36,35 +41,90 @@ const (
 	MIN       = "MIN"
 	MAX       = "MAX"
 	PER99TH   = "PER99TH"
-	PER999TH  = "PER999TH"
-	PER9999TH = "PER9999TH"
+	PER999TH  = "PER99_9TH"
+	PER9999TH = "PER99_99TH"
 )
 
-func newHistogram() *histogram {
+// histogramOptions holds the reported percentiles and the latency unit
+// shared by all the histograms.
+type histogramOptions struct {
+	percentiles []float64
+	unit        time.Duration
+	unitName    string
+}
+
+func newHistogramOptions(p *properties.Properties) *histogramOptions {
+	opts := new(histogramOptions)
+
+	unitName := p.GetString(prop.LatencyUnit, prop.LatencyUnitDefault)
+	switch strings.ToLower(unitName) {
+	case "ns":
+		opts.unit, opts.unitName = time.Nanosecond, "ns"
+	case "us", "µs":
+		opts.unit, opts.unitName = time.Microsecond, "us"
+	case "ms":
+		opts.unit, opts.unitName = time.Millisecond, "ms"
+	default:
+		util.Fatalf("unknown latency unit %s, must be one of ns, us or ms", unitName)
+	}
+
+	for _, s := range strings.Split(p.GetString(prop.Percentiles, prop.PercentilesDefault), ",") {
+		s = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(s)), "p")
+		if len(s) == 0 {
+			continue
+		}
+		per, err := strconv.ParseFloat(s, 64)
+		if err != nil || per <= 0 || per > 100 {
+			util.Fatalf("invalid percentile %q in %s", s, prop.Percentiles)
+		}
+		opts.percentiles = append(opts.percentiles, per)
+	}
+	return opts
+}
+
+// percentileName returns the metric name of the percentile, e.g. PER99_9TH
+// for 99.9, the dot is kept as an underscore so 9.99 and 99.9 don't collide.
+func percentileName(per float64) string {
+	return "PER" + strings.Replace(strconv.FormatFloat(per, 'f', -1, 64), ".", "_", 1) + "TH"
+}
+
+// header returns the summary columns in the same order as histogram.Summary.
+func (o *histogramOptions) header() []string {
+	header := []string{"Operation", "Takes(s)", "Count", "OPS",
+		"Avg(" + o.unitName + ")", "Min(" + o.unitName + ")", "Max(" + o.unitName + ")"}
+	for _, per := range o.percentiles {
+		header = append(header, strconv.FormatFloat(per, 'f', -1, 64)+"th("+o.unitName+")")
+	}
+	return header
+}
+
+func newHistogram(opts *histogramOptions) *histogram {
 	h := new(histogram)
 	h.startTime = time.Now()
-	h.hist = hdrhistogram.New(1, 24*60*60*1000*1000, 3)
+	h.opts = opts
+	h.hist = hdrhistogram.New(1, int64(24*time.Hour/opts.unit), 3)
 	return h
 }
 
 func (h *histogram) Measure(latency time.Duration) {
-	h.hist.RecordValue(latency.Microseconds())
+	h.hist.RecordValue(int64(latency / h.opts.unit))
 }
 
 func (h *histogram) Summary() []string {
 	res := h.getInfo()
 
-	return []string{
+	summary := []string{
 		util.FloatToOneString(res[ELAPSED]),
 		util.IntToString(res[COUNT]),
 		util.FloatToOneString(res[QPS]),
 		util.IntToString(res[AVG]),
 		util.IntToString(res[MIN]),
 		util.IntToString(res[MAX]),
-		util.IntToString(res[PER99TH]),
-		util.IntToString(res[PER999TH]),
-		util.IntToString(res[PER9999TH]),
 	}
+	for _, per := range h.opts.percentiles {
+		summary = append(summary, util.IntToString(res[percentileName(per)]))
+	}
+	return summary
 }
 
 func (h *histogram) getInfo() map[string]interface{} {
@@ -76,10 +136,6 @@ func (h *histogram) getInfo() map[string]interface{} {
 	bounds := h.boundCounts.Keys()
 	sort.Ints(bounds)
 
-	per99 := h.hist.ValueAtPercentile(99)
-	per999 := h.hist.ValueAtPercentile(99.9)
-	per9999 := h.hist.ValueAtPercentile(99.99)
-
 	elapsed := time.Now().Sub(h.startTime).Seconds()
 	qps := float64(count) / elapsed
 	res := make(map[string]interface{})
@@ -89,9 +145,9 @@ func (h *histogram) getInfo() map[string]interface{} {
 	res[AVG] = avg
 	res[MIN] = min
 	res[MAX] = max
-	res[PER99TH] = per99
-	res[PER999TH] = per999
-	res[PER9999TH] = per9999
+	for _, per := range h.opts.percentiles {
+		res[percentileName(per)] = h.hist.ValueAtPercentile(per)
+	}
 
 	return res
 }
@@ -1,7 +1,6 @@
 package measurement
 
 import (
-	"fmt"
 	"io"
 	"os"
 	"sort"
@@ -10,18 +9,20 @@ import (
 	"github.com/magiconair/properties"
 	"github.com/pingcap/go-ycsb/pkg/prop"
 	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
 )
 
 type histograms struct {
 	p *properties.Properties
 
+	opts       *histogramOptions
 	histograms map[string]*histogram
 }
 
 func (h *histograms) Measure(op string, start time.Time, lan time.Duration) {
 	opM, ok := h.histograms[op]
 	if !ok {
-		opM = newHistogram()
+		opM = newHistogram(h.opts)
 		h.histograms[op] = opM
 	}
 
@@ -55,6 +56,7 @@ func (h *histograms) Output(w io.Writer) error {
 		lines = append(lines, line)
 	}
 
+	header := h.opts.header()
 	outputStyle := h.p.GetString(prop.OutputStyle, util.OutputStylePlain)
 	switch outputStyle {
 	case util.OutputStylePlain:
@@ -69,22 +71,24 @@ func (h *histograms) Output(w io.Writer) error {
 	return nil
 }
 
-func (h *histograms) ExportLatency(w io.Writer) {
-	indexList := make([]float64, 0, 10000)
-	for i := 0.01; i <= 100.0; i += 0.01 {
-		indexList = append(indexList, i)
+func (h *histograms) Results() []ycsb.OpResult {
+	results := make([]ycsb.OpResult, 0, len(h.histograms))
+	for op, opM := range h.histograms {
+		results = append(results, ycsb.OpResult{
+			Op:      op,
+			Elapsed: time.Now().Sub(opM.startTime),
+			Hist:    opM.hist,
+			Unit:    h.opts.unit,
+		})
 	}
-	histogram, _ := h.histograms["total"]
-	bracketList := histogram.hist.ValueAtPercentiles(indexList)
-		for q, bracket := range bracketList {
-			fmt.Fprintln(w, fmt.Sprintf("%v %v", q, bracket))
-		}
-
+	sort.Slice(results, func(i, j int) bool { return results[i].Op < results[j].Op })
+	return results
 }
 
 func InitHistograms(p *properties.Properties) *histograms {
 	return &histograms{
 		p:          p,
+		opts:       newHistogramOptions(p),
 		histograms: make(map[string]*histogram, 16),
 	}
 }
@@ -15,6 +15,8 @@ package measurement
 
 import (
 	"bufio"
+	"fmt"
+	"io"
 	"os"
 	"sync"
 	"sync/atomic"
@@ -22,17 +24,18 @@ import (
 
 	"github.com/magiconair/properties"
 	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
 	"github.com/pingcap/go-ycsb/pkg/ycsb"
 )
 
-var header = []string{"Operation", "Takes(s)", "Count", "OPS", "Avg(us)", "Min(us)", "Max(us)", "99th(us)", "99.9th(us)", "99.99th(us)"}
-
 type measurement struct {
 	sync.RWMutex
 
 	p *properties.Properties
 
 	measurer ycsb.Measurer
+
+	clientMonitor *clientMonitor
 }
 
 func (m *measurement) measure(op string, start time.Time, lan time.Duration) {
@@ -63,28 +66,103 @@ func (m *measurement) output() {
 		panic("failed to write output: " + err.Error())
 	}
 
+	m.outputRates(w)
+
+	if m.clientMonitor != nil {
+		m.clientMonitor.output(w, clientStatsTotalOp, m.clientMonitor.total())
+	}
+
 	err = w.Flush()
 	if err != nil {
 		panic("failed to flush output: " + err.Error())
 	}
 
+	m.export()
+}
+
+func (m *measurement) export() {
+	exporterName, ok := m.p.Get(prop.Exporter)
 	exportFile := m.p.GetString(prop.ExportFile, "")
-	if exportFile != "" {
-		f, err := os.Create(exportFile)
-		if err != nil {
-			panic("failed to create output file: " + err.Error())
+	if !ok && exportFile == "" {
+		return
+	}
+	if !ok {
+		exporterName = prop.ExporterDefault
+	}
+
+	exporterCreator := ycsb.GetExporterCreator(exporterName)
+	if exporterCreator == nil {
+		util.Fatalf("unsupported exporter %s", exporterName)
+	}
+	exporter, err := exporterCreator.Create(m.p)
+	if err != nil {
+		util.Fatalf("failed to create exporter %s: %v", exporterName, err)
+	}
+	results := m.measurer.Results()
+
+	if exportFile == "" {
+		w := bufio.NewWriter(os.Stdout)
+		if err = exporter.Export(w, results); err != nil {
+			util.Fatalf("failed to export: %v", err)
+		}
+		if err = w.Flush(); err != nil {
+			util.Fatalf("failed to flush export: %v", err)
 		}
-		fileWriter := bufio.NewWriter(f)
-		globalMeasure.measurer.ExportLatency(fileWriter)
-		fileWriter.Flush()
-		f.Close()
+		return
+	}
+
+	// Write to a temporary file and rename it, so a collector watching the
+	// export file never reads a partial result.
+	tmpFile := exportFile + ".tmp"
+	f, err := os.Create(tmpFile)
+	if err != nil {
+		util.Fatalf("failed to create export file: %v", err)
+	}
+	fileWriter := bufio.NewWriter(f)
+	if err = exporter.Export(fileWriter, results); err != nil {
+		util.Fatalf("failed to export: %v", err)
+	}
+	if err = fileWriter.Flush(); err != nil {
+		util.Fatalf("failed to flush export file: %v", err)
+	}
+	f.Close()
+	if err = os.Rename(tmpFile, exportFile); err != nil {
+		util.Fatalf("failed to rename export file: %v", err)
+	}
+}
+
+// outputRates writes the success rate of the operations registered by
+// ReportSuccessRate which were measured.
+func (m *measurement) outputRates(w io.Writer) {
+	ratesLock.Lock()
+	defer ratesLock.Unlock()
+	if len(rates) == 0 {
+		return
+	}
+
+	counts := make(map[string]int64)
+	for _, result := range m.measurer.Results() {
+		counts[result.Op] = result.Hist.TotalCount()
+	}
+	for _, rate := range rates {
+		total, failed := counts[rate.op], counts[rate.failedOp]
+		if total == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%s - Success(%%): %.2f, %s: %d of %d\n",
+			rate.op, 100*float64(total-failed)/float64(total), rate.failedOp, failed, total)
 	}
 }
 
 func (m *measurement) summary() {
 	m.RLock()
 	globalMeasure.measurer.Summary()
+	m.outputRates(os.Stdout)
 	m.RUnlock()
+
+	if m.clientMonitor != nil {
+		m.clientMonitor.output(os.Stdout, clientStatsOpName, m.clientMonitor.interval())
+	}
 }
 
 // InitMeasure initializes the global measurement.
@@ -100,7 +178,24 @@ func InitMeasure(p *properties.Properties) {
 	default:
 		panic("unsupported measurement type: " + measurementType)
 	}
+	if p.GetBool(prop.ClientStats, prop.ClientStatsDefault) {
+		globalMeasure.clientMonitor = newClientMonitor(p)
+	}
 	EnableWarmUp(p.GetInt64(prop.WarmUpTime, 0) > 0)
+	perOp = p.GetBool(prop.PerOp, prop.PerOpDefault)
+}
+
+// ReportSuccessRate reports the share of the measurements of op which are not
+// measured as failedOp too, next to the summaries and the output.
+func ReportSuccessRate(op string, failedOp string) {
+	ratesLock.Lock()
+	defer ratesLock.Unlock()
+	for _, rate := range rates {
+		if rate.op == op {
+			return
+		}
+	}
+	rates = append(rates, successRate{op: op, failedOp: failedOp})
 }
 
 // Output prints the complete measurements.
@@ -108,6 +203,13 @@ func Output() {
 	globalMeasure.output()
 }
 
+// Results returns the measurement results of all operations.
+func Results() []ycsb.OpResult {
+	globalMeasure.RLock()
+	defer globalMeasure.RUnlock()
+	return globalMeasure.measurer.Results()
+}
+
 // Summary prints the measurement summary.
 func Summary() {
 	globalMeasure.summary()
@@ -127,6 +229,11 @@ func IsWarmUpFinished() bool {
 	return atomic.LoadInt32(&warmUp) == 0
 }
 
+// IsPerOpEnabled returns whether every operation is measured on its own, besides the total.
+func IsPerOpEnabled() bool {
+	return perOp
+}
+
 // Measure measures the operation.
 func Measure(op string, start time.Time, lan time.Duration) {
 	if IsWarmUpFinished() {
@@ -135,4 +242,15 @@ func Measure(op string, start time.Time, lan time.Duration) {
 }
 
 var globalMeasure *measurement
+
+type successRate struct {
+	op       string
+	failedOp string
+}
+
+var (
+	ratesLock sync.Mutex
+	rates     []successRate
+)
 var warmUp int32 // use as bool, 1 means in warmup progress, 0 means warmup finished.
+var perOp bool
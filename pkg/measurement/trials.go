@@ -0,0 +1,204 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package measurement
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// tTable95 holds the two-sided 95% critical values of Student's t
+// distribution for 1 to 30 degrees of freedom.
+var tTable95 = []float64{
+	12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+	2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+	2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+}
+
+// outlierThreshold is the modified z-score above which a trial is an outlier.
+const outlierThreshold = 3.5
+
+func tCritical95(df int) float64 {
+	if df <= 0 {
+		return math.NaN()
+	}
+	if df <= len(tTable95) {
+		return tTable95[df-1]
+	}
+	return 1.96
+}
+
+func meanStdDev(values []float64) (float64, float64) {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	if len(values) < 2 {
+		return mean, 0
+	}
+
+	var sq float64
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(sq / float64(len(values)-1))
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// outliers returns the indexes of the values whose modified z-score, based on
+// the median absolute deviation, exceeds outlierThreshold.
+func outliers(values []float64) []int {
+	if len(values) < 3 {
+		return nil
+	}
+	med := median(values)
+	deviations := make([]float64, len(values))
+	for i, v := range values {
+		deviations[i] = math.Abs(v - med)
+	}
+	mad := median(deviations)
+	if mad == 0 {
+		return nil
+	}
+
+	var res []int
+	for i, d := range deviations {
+		if 0.6745*d/mad > outlierThreshold {
+			res = append(res, i)
+		}
+	}
+	return res
+}
+
+// trialMetric is one metric of one operation across all trials.
+type trialMetric struct {
+	op     string
+	name   string
+	values []float64
+}
+
+func collectTrialMetrics(trials [][]ycsb.OpResult, percentiles []float64) []*trialMetric {
+	var metrics []*trialMetric
+	index := make(map[string]*trialMetric)
+	add := func(trial int, op string, name string, v float64) {
+		key := op + "\x00" + name
+		m, ok := index[key]
+		if !ok {
+			m = &trialMetric{op: op, name: name, values: make([]float64, len(trials))}
+			for i := range m.values {
+				m.values[i] = math.NaN()
+			}
+			index[key] = m
+			metrics = append(metrics, m)
+		}
+		m.values[trial] = v
+	}
+
+	for i, results := range trials {
+		for _, r := range results {
+			unit := unitName(r.Unit)
+			add(i, r.Op, "OPS", opsPerSecond(r))
+			add(i, r.Op, "Avg("+unit+")", r.Hist.Mean())
+			for _, per := range percentiles {
+				add(i, r.Op, formatPercentile(per)+"th("+unit+")", float64(r.Hist.ValueAtPercentile(per)))
+			}
+		}
+	}
+	sort.SliceStable(metrics, func(i, j int) bool { return metrics[i].op < metrics[j].op })
+	return metrics
+}
+
+// OutputTrials writes the mean, standard deviation and 95% confidence interval
+// of the throughput and latency percentiles of every operation across trials,
+// and flags the trials that are outliers.
+func OutputTrials(w io.Writer, trials [][]ycsb.OpResult) {
+	if len(trials) == 0 {
+		return
+	}
+	p := globalMeasure.p
+	percentiles := newHistogramOptions(p).percentiles
+
+	header := []string{"Operation", "Metric", "Trials", "Mean", "StdDev", "CI95Low", "CI95High", "Outliers"}
+	var lines [][]string
+	var warnings []string
+	for _, m := range collectTrialMetrics(trials, percentiles) {
+		values := make([]float64, 0, len(m.values))
+		trialIDs := make([]int, 0, len(m.values))
+		for i, v := range m.values {
+			if !math.IsNaN(v) {
+				values = append(values, v)
+				trialIDs = append(trialIDs, i+1)
+			}
+		}
+
+		mean, stdDev := meanStdDev(values)
+		low, high := mean, mean
+		if len(values) > 1 {
+			delta := tCritical95(len(values)-1) * stdDev / math.Sqrt(float64(len(values)))
+			low, high = mean-delta, mean+delta
+		}
+
+		var outlierIDs []string
+		for _, i := range outliers(values) {
+			outlierIDs = append(outlierIDs, strconv.Itoa(trialIDs[i]))
+			warnings = append(warnings, fmt.Sprintf("trial %d is an outlier for %s %s: %.1f, the median is %.1f",
+				trialIDs[i], m.op, m.name, values[i], median(values)))
+		}
+
+		lines = append(lines, []string{
+			m.op,
+			m.name,
+			strconv.Itoa(len(values)),
+			util.FloatToOneString(mean),
+			util.FloatToOneString(stdDev),
+			util.FloatToOneString(low),
+			util.FloatToOneString(high),
+			strings.Join(outlierIDs, " "),
+		})
+	}
+
+	outputStyle := p.GetString(prop.OutputStyle, util.OutputStylePlain)
+	switch outputStyle {
+	case util.OutputStylePlain:
+		util.RenderString(w, "%-6s - %s\n", header, lines)
+	case util.OutputStyleJson:
+		util.RenderJson(w, header, lines)
+	case util.OutputStyleTable:
+		util.RenderTable(w, header, lines)
+	default:
+		panic("unsupported outputstyle: " + outputStyle)
+	}
+
+	for _, warning := range warnings {
+		fmt.Fprintf(w, "WARNING: %s\n", warning)
+	}
+}
@@ -13,6 +13,8 @@
 
 package prop
 
+import "time"
+
 // Properties
 const (
 	InsertStart        = "insertstart"
@@ -25,6 +27,7 @@ const (
 	Workload           = "workload"
 	DB                 = "db"
 	Exporter           = "exporter"
+	ExporterDefault    = "latency"
 	ExportFile         = "exportfile"
 	ThreadCount        = "threadcount"
 	ThreadCountDefault = int64(200)
@@ -34,6 +37,11 @@ const (
 	DoTransactions     = "dotransactions"
 	Status             = "status"
 	Label              = "label"
+	// repeat the run phase, reporting statistics across the trials
+	Repeat         = "repeat"
+	RepeatDefault  = 1
+	RepeatCoolDown = "repeat.cooldown"
+	RepeatReload   = "repeat.reload"
 	// batch mode
 	BatchSize        = "batch.size"
 	DefaultBatchSize = int(1)
@@ -42,7 +50,7 @@ const (
 	TableNameDefault  = "usertable"
 	FieldCount        = "fieldcount"
 	FieldCountDefault = int64(10)
-	// "uniform", "zipfian", "constant", "histogram"
+	// "uniform", "zipfian", "constant", "histogram", or a distribution spec
 	FieldLengthDistribution        = "fieldlengthdistribution"
 	FieldLengthDistributionDefault = "constant"
 	FieldLength                    = "fieldlength"
@@ -66,14 +74,124 @@ const (
 	ScanProportionDefault            = float64(0.0)
 	ReadModifyWriteProportion        = "readmodifywriteproportion"
 	ReadModifyWriteProportionDefault = float64(0.0)
-	// "uniform", "zipfian", "latest"
+	DeleteProportion                 = "deleteproportion"
+	DeleteProportionDefault          = float64(0.0)
+	CASProportion                    = "casproportion"
+	CASProportionDefault             = float64(0.0)
+
+	// the proportions of the batch operations mixed with the single ones
+	BatchReadProportion            = "batchreadproportion"
+	BatchUpdateProportion          = "batchupdateproportion"
+	BatchInsertProportion          = "batchinsertproportion"
+	BatchScanProportion            = "batchscanproportion"
+	BatchReadModifyWriteProportion = "batchreadmodifywriteproportion"
+	BatchDeleteProportion          = "batchdeleteproportion"
+	// the distribution specs of the sizes of the batch operations, drawn in
+	// [1, 100] unless the spec has its own range, batchopsize by default
+	BatchReadSize            = "batchreadsize"
+	BatchUpdateSize          = "batchupdatesize"
+	BatchInsertSize          = "batchinsertsize"
+	BatchScanSize            = "batchscansize"
+	BatchReadModifyWriteSize = "batchreadmodifywritesize"
+	BatchDeleteSize          = "batchdeletesize"
+	BatchOpSize              = "batchopsize"
+	BatchOpSizeDefault       = "uniform(1, 16)"
+
+	// "oldest", "chooser"
+	DeleteOrder        = "deleteorder"
+	DeleteOrderDefault = "oldest"
+
+	// txn workload
+	TxnReadCount         = "txn.readcount"
+	TxnReadCountDefault  = int64(2)
+	TxnWriteCount        = "txn.writecount"
+	TxnWriteCountDefault = int64(2)
+	// how many times a conflicting transaction is retried before aborting it
+	TxnMaxRetries        = "txn.maxretries"
+	TxnMaxRetriesDefault = int64(3)
+
+	// bank workload
+	BankInitialBalance        = "bank.initialbalance"
+	BankInitialBalanceDefault = int64(1000)
+	BankMaxTransfer           = "bank.maxtransfer"
+	BankMaxTransferDefault    = int64(100)
+	// how often to check the total balance during the run, e.g. "10s", 0 checks at the end only
+	BankCheckInterval = "bank.checkinterval"
+
+	// lease workload, the number of live leases held across the threads,
+	// their TTL and the number of keys attached to every lease
+	LeaseCount        = "lease.count"
+	LeaseCountDefault = int64(1000)
+	LeaseTTL          = "lease.ttl"
+	LeaseTTLDefault   = 10 * time.Second
+	LeaseKeys         = "lease.keys"
+	LeaseKeysDefault  = int64(1)
+	// the proportions of the operations on the live leases
+	LeaseKeepAliveProportion        = "lease.keepaliveproportion"
+	LeaseKeepAliveProportionDefault = float64(0.2)
+	LeasePutProportion              = "lease.putproportion"
+	LeasePutProportionDefault       = float64(0.6)
+	LeaseRevokeProportion           = "lease.revokeproportion"
+	LeaseRevokeProportionDefault    = float64(0.1)
+	LeaseExpireProportion           = "lease.expireproportion"
+	LeaseExpireProportionDefault    = float64(0.1)
+	// how often a lease left to expire is read until its keys are gone
+	LeaseExpiryCheckInterval        = "lease.expirycheckinterval"
+	LeaseExpiryCheckIntervalDefault = 100 * time.Millisecond
+
+	// watch workload, the number of the goroutines watching the table, and
+	// how long to wait for the events in flight after the run
+	WatchWatchers            = "watch.watchers"
+	WatchWatchersDefault     = int64(1)
+	WatchDrainTimeout        = "watch.draintimeout"
+	WatchDrainTimeoutDefault = 5 * time.Second
+
+	// virtual users multiplexed on every thread. The think time between two
+	// operations of a user and the idle time between two of its sessions
+	// are distribution specs in milliseconds, "user.thinktime.<thread>"
+	// overrides the think time of a thread. The number of operations of a
+	// session is a distribution spec too, empty for one endless session.
+	UserCount        = "user.count"
+	UserCountDefault = int64(1)
+	UserThinkTime    = "user.thinktime"
+	UserIdleTime     = "user.idletime"
+	UserSessionOps   = "user.sessionops"
+	// the number of related records accessed by a session from the first
+	// one it chooses, 0 for records chosen independently
+	UserSessionKeys        = "user.sessionkeys"
+	UserSessionKeysDefault = int64(0)
+
+	// the fields of the records, e.g. "id:int64,name:string:32,doc:json",
+	// or a file of them, by default fieldcount bytes fields
+	Schema     = "schema"
+	SchemaFile = "schemafile"
+
+	// the content of the values, "random", "text", "repeated", "json" or
+	// "zero", and the target compression ratio in (0, 1], "valuemode.<field>"
+	// and "compressionratio.<field>" override them for one field
+	ValueMode               = "valuemode"
+	ValueModeDefault        = "random"
+	CompressionRatio        = "compressionratio"
+	CompressionRatioDefault = float64(1)
+
+	// multitable workload, the comma separated names of the tables
+	MultiTableTables = "multitable.tables"
+	// the prefix of the properties of one table, e.g. "table.users.recordcount"
+	// overrides "recordcount" for the table users
+	MultiTablePrefix = "table."
+	// the weight of a table in the operations of the run phase, set per table
+	MultiTableShare        = "share"
+	MultiTableShareDefault = float64(1)
+
+	// "uniform", "zipfian", "latest", "hotspot", "movinghotspot", "jumpinghotspot",
+	// "conflict", "normal", or a distribution spec, see generator.ParseSpec
 	RequestDistribution        = "requestdistribution"
 	RequestDistributionDefault = "uniform"
 	ZeroPadding                = "zeropadding"
 	ZeroPaddingDefault         = int64(1)
 	MaxScanLength              = "maxscanlength"
 	MaxScanLengthDefault       = int64(1000)
-	// "uniform", "zipfian"
+	// "uniform", "zipfian", or a distribution spec
 	ScanLengthDistribution        = "scanlengthdistribution"
 	ScanLengthDistributionDefault = "uniform"
 	// "ordered", "hashed"
@@ -88,6 +206,28 @@ const (
 	InsertionRetryInterval        = "core_workload_insertion_retry_interval"
 	InsertionRetryIntervalDefault = int64(3)
 
+	// the number of keys per second the hot set of "movinghotspot" moves by
+	HotspotSpeed        = "hotspotspeed"
+	HotspotSpeedDefault = float64(100)
+	// how long the hot set of "jumpinghotspot" stays at one place, e.g. "10s"
+	HotspotPeriod = "hotspotperiod"
+
+	// the "conflict" and "normal" distributions of the Paxi benchmark
+	// percentage of operations accessing the shared keys
+	Conflicts                 = "conflicts"
+	ConflictsDefault          = float64(100)
+	ConflictSharedKeys        = "conflict.sharedkeys"
+	ConflictSharedKeysDefault = int64(1)
+	NormalMu                  = "normal.mu"
+	NormalMuDefault           = float64(0)
+	NormalSigma               = "normal.sigma"
+	NormalSigmaDefault        = float64(60)
+	NormalMove                = "normal.move"
+	NormalMoveDefault         = false
+	// milliseconds mu stays on a key before moving to the next one
+	NormalSpeed        = "normal.speed"
+	NormalSpeedDefault = int64(500)
+
 	ExponentialPercentile        = "exponential.percentile"
 	ExponentialPercentileDefault = float64(95)
 	ExponentialFrac              = "exponential.frac"
@@ -107,11 +247,74 @@ const (
 	KeyPrefix        = "keyprefix"
 	KeyPrefixDefault = "user-prefix"
 
+	// the format of the keys: empty for keyprefix and the record number,
+	// "binary" for keyprefix and the 8 bytes big-endian record number, or a
+	// template like "tenant{tenant}:user{hash:016x}"
+	KeyFormat        = "keyformat"
+	KeyFormatDefault = ""
+	// pads the keys of a template to a length of keylengthdistribution in
+	// [1, keylength], 0 keeps the natural length
+	KeyLength                    = "keylength"
+	KeyLengthDefault             = int64(0)
+	KeyLengthDistribution        = "keylengthdistribution"
+	KeyLengthDistributionDefault = "constant"
+	// the {client} of a key template
+	KeyClient        = "keyclient"
+	KeyClientDefault = "0"
+	// the number of the {tenant}s of a key template
+	KeyTenants        = "keytenants"
+	KeyTenantsDefault = int64(1)
+	// the number of the {shard}s of a key template, a fixed partition of the
+	// records which must not change between the load and the run
+	KeyShards        = "keyshards"
+	KeyShardsDefault = int64(1)
+
 	LogInterval = "measurement.interval"
 
 	MeasurementType          = "measurementtype"
 	MeasurementTypeDefault   = "histogram"
 	MeasurementRawOutputFile = "measurement.output_file"
+	// measure every operation on its own besides the total
+	PerOp        = "measurement.perop"
+	PerOpDefault = false
+	// comma separated percentiles reported by the histograms, e.g. "50,90,99,99.999"
+	Percentiles        = "measurement.percentiles"
+	PercentilesDefault = "99,99.9,99.99"
+	// "ns", "us", "ms"
+	LatencyUnit        = "measurement.latency_unit"
+	LatencyUnitDefault = "us"
+	// "both", "corrected", "uncorrected", only takes effect when target is set
+	CoordinatedOmission        = "measurement.coordinated_omission"
+	CoordinatedOmissionDefault = "both"
+
+	// client resource self-monitoring
+	ClientStats                    = "measurement.clientstats"
+	ClientStatsDefault             = false
+	ClientStatsGCThreshold         = "measurement.clientstats.gc_threshold"
+	ClientStatsGCThresholdDefault  = float64(10)
+	ClientStatsCPUThreshold        = "measurement.clientstats.cpu_threshold"
+	ClientStatsCPUThresholdDefault = float64(90)
+
+	// record the history of every register and check it is linearizable after the run
+	LinearizabilityCheck        = "linearizability.check"
+	LinearizabilityCheckDefault = false
+	// the time limit of the check, e.g. "1m"
+	LinearizabilityTimeout = "linearizability.timeout"
+
+	// check the read-your-writes, monotonic reads and monotonic writes guarantees of every thread
+	SessionCheck        = "session.check"
+	SessionCheckDefault = false
+
+	// how the verify command reads the records: "scan" if the database
+	// returns the keys of a scan, "read" one by one, or "auto"
+	VerifyMode        = "verify.mode"
+	VerifyModeDefault = "auto"
+	// the number of records of every scan of the verify command
+	VerifyScanCount        = "verify.scancount"
+	VerifyScanCountDefault = 1000
+	// the number of keys printed of every kind of bad records
+	VerifySamples        = "verify.samples"
+	VerifySamplesDefault = 10
 
 	Command = "command"
 
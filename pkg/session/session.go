@@ -0,0 +1,250 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package session checks the client-centric consistency guarantees of
+// Terry et al.: read-your-writes, monotonic reads and monotonic writes.
+//
+// Every worker thread is a session. The writes of a session embed a header
+// with the session id and a per-session sequence number in every field
+// value, so a read tells which write it observed. Writes of one session
+// are ordered, and the loaded values are older than any write of the run,
+// so a read definitely observed an older version than one the session
+// depends on if it returns a loaded value, or a value of the same writer
+// with a lower sequence number. Versions of different writers can't be
+// compared without knowing the order the database applied them in.
+package session
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+)
+
+// headerLen is the length of the "#<session>:<seq>#" header, with the
+// session and seq in fixed width hex.
+const headerLen = 1 + 8 + 1 + 16 + 1
+
+type contextKey string
+
+const sessionKey = contextKey("session")
+
+type register struct {
+	table string
+	key   string
+	field string
+}
+
+// version identifies the write of a value. The zero version is a loaded
+// value without a header.
+type version struct {
+	session uint32
+	seq     uint64
+}
+
+// olderThan reports whether v is definitely older than the write seq of
+// the session writer.
+func (v version) olderThan(writer uint32, seq uint64) bool {
+	return v.session == 0 || (v.session == writer && v.seq < seq)
+}
+
+// Session is the state of a worker thread.
+type Session struct {
+	id  uint32
+	seq uint64
+
+	// writes holds the sequence numbers of the successful writes of every
+	// register in ascending order, the other sessions read it to check
+	// monotonic writes.
+	mu     sync.RWMutex
+	writes map[register][]uint64
+
+	// lastRead is the version last read of every register.
+	lastRead map[register]version
+	// observed is the highest sequence number read of every other session.
+	observed map[uint32]uint64
+}
+
+var (
+	enabled  bool
+	sessions sync.Map
+)
+
+// Init enables the check for the run phase if it is configured, and
+// discards the previous sessions.
+func Init(p *properties.Properties) {
+	enabled = p.GetBool(prop.SessionCheck, prop.SessionCheckDefault) && p.GetBool(prop.DoTransactions, true)
+	sessions = sync.Map{}
+}
+
+// IsEnabled returns whether the session guarantees are checked.
+func IsEnabled() bool {
+	return enabled
+}
+
+// NewContext returns a context carrying a new session.
+func NewContext(ctx context.Context) context.Context {
+	s := &Session{
+		writes:   make(map[register][]uint64),
+		lastRead: make(map[register]version),
+		observed: make(map[uint32]uint64),
+	}
+	// The id is random, so the sessions of concurrent go-ycsb processes
+	// don't mix.
+	for {
+		s.id = rand.Uint32()
+		if _, loaded := sessions.LoadOrStore(s.id, s); s.id != 0 && !loaded {
+			break
+		}
+	}
+	return context.WithValue(ctx, sessionKey, s)
+}
+
+// FromContext returns the session of the context, or nil.
+func FromContext(ctx context.Context) *Session {
+	s, _ := ctx.Value(sessionKey).(*Session)
+	return s
+}
+
+func parseHeader(v []byte) (version, bool) {
+	if len(v) < headerLen || v[0] != '#' || v[9] != ':' || v[headerLen-1] != '#' {
+		return version{}, false
+	}
+	session, err := strconv.ParseUint(string(v[1:9]), 16, 32)
+	if err != nil {
+		return version{}, false
+	}
+	seq, err := strconv.ParseUint(string(v[10:headerLen-1]), 16, 64)
+	if err != nil {
+		return version{}, false
+	}
+	return version{session: uint32(session), seq: seq}, true
+}
+
+// Stamp returns a copy of values with the header of the next write of the
+// session, and the sequence number to pass to Wrote once it succeeds.
+func (s *Session) Stamp(values map[string][]byte) (map[string][]byte, uint64) {
+	s.seq++
+	header := fmt.Sprintf("#%08x:%016x#", s.id, s.seq)
+	stamped := make(map[string][]byte, len(values))
+	for field, v := range values {
+		buf := make([]byte, 0, headerLen+len(v))
+		buf = append(buf, header...)
+		stamped[field] = append(buf, v...)
+	}
+	return stamped, s.seq
+}
+
+// Wrote records the successful write seq of the fields of a record.
+func (s *Session) Wrote(table string, key string, values map[string][]byte, seq uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for field := range values {
+		r := register{table: table, key: key, field: field}
+		s.writes[r] = append(s.writes[r], seq)
+	}
+}
+
+// lastWrite returns the sequence number of the last write of r at or
+// before seq, or 0.
+func (s *Session) lastWrite(r register, seq uint64) uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	writes := s.writes[r]
+	i := sort.Search(len(writes), func(i int) bool { return writes[i] > seq })
+	if i == 0 {
+		return 0
+	}
+	return writes[i-1]
+}
+
+// Read checks the values read from a record against the writes the session
+// wrote and observed, measures the violations as op_RYW_VIOLATION,
+// op_MR_VIOLATION and op_MW_VIOLATION, and returns the values without the
+// headers. The absent fields are not checked, they may have been deleted.
+func (s *Session) Read(op string, start time.Time, table string, key string, values map[string][]byte) map[string][]byte {
+	var ryw, mr, mw bool
+	versions := make(map[uint32]uint64)
+	for field, value := range values {
+		r := register{table: table, key: key, field: field}
+		v, _ := parseHeader(value)
+
+		if w := s.lastWrite(r, s.seq); w > 0 && v.olderThan(s.id, w) {
+			ryw = true
+		}
+
+		if last, ok := s.lastRead[r]; ok && last.session != 0 && v.olderThan(last.session, last.seq) {
+			mr = true
+		}
+		s.lastRead[r] = v
+
+		// The writes of a session are ordered, so once the session observed
+		// a write of another one, it must observe its previous writes too.
+		if !mw {
+			for writer, seq := range s.observed {
+				other, ok := sessions.Load(writer)
+				if !ok {
+					continue
+				}
+				if w := other.(*Session).lastWrite(r, seq); w > 0 && v.olderThan(writer, w) {
+					mw = true
+					break
+				}
+			}
+		}
+
+		if v.session != 0 && v.session != s.id && v.seq > versions[v.session] {
+			versions[v.session] = v.seq
+		}
+	}
+
+	for writer, seq := range versions {
+		if seq > s.observed[writer] {
+			s.observed[writer] = seq
+		}
+	}
+
+	lan := time.Now().Sub(start)
+	if ryw {
+		measurement.Measure(op+"_RYW_VIOLATION", start, lan)
+	}
+	if mr {
+		measurement.Measure(op+"_MR_VIOLATION", start, lan)
+	}
+	if mw {
+		measurement.Measure(op+"_MW_VIOLATION", start, lan)
+	}
+	return Strip(values)
+}
+
+// Strip returns the values without the session headers.
+func Strip(values map[string][]byte) map[string][]byte {
+	if values == nil {
+		return nil
+	}
+	stripped := make(map[string][]byte, len(values))
+	for field, v := range values {
+		if _, ok := parseHeader(v); ok {
+			v = v[headerLen:]
+		}
+		stripped[field] = v
+	}
+	return stripped
+}
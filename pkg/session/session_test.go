@@ -0,0 +1,125 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+)
+
+func newSessions(n int) []*Session {
+	p := properties.MustLoadString("session.check=true")
+	Init(p)
+	measurement.InitMeasure(p)
+	ss := make([]*Session, n)
+	for i := range ss {
+		ss[i] = FromContext(NewContext(context.Background()))
+	}
+	return ss
+}
+
+func measured(op string) int64 {
+	for _, result := range measurement.Results() {
+		if result.Op == op {
+			return result.Hist.TotalCount()
+		}
+	}
+	return 0
+}
+
+func TestStampStrip(t *testing.T) {
+	s := newSessions(1)[0]
+	values := map[string][]byte{"f0": []byte("a"), "f1": []byte("")}
+	stamped, seq := s.Stamp(values)
+	if seq != 1 {
+		t.Fatalf("expect the first write to be 1, got %d", seq)
+	}
+	for field, v := range stamped {
+		version, ok := parseHeader(v)
+		if !ok || version.session != s.id || version.seq != seq {
+			t.Fatalf("field %s has the header of %v, expect %d:%d", field, version, s.id, seq)
+		}
+	}
+	if string(values["f0"]) != "a" {
+		t.Fatal("Stamp must not modify the values")
+	}
+
+	stripped := Strip(stamped)
+	if len(stripped) != 2 || string(stripped["f0"]) != "a" || string(stripped["f1"]) != "" {
+		t.Fatalf("expect the written values, got %q", stripped)
+	}
+	// a loaded value has no header.
+	if loaded := Strip(values); string(loaded["f0"]) != "a" {
+		t.Fatalf("expect the loaded value, got %q", loaded["f0"])
+	}
+	if Strip(nil) != nil {
+		t.Fatal("expect nil for no values")
+	}
+	if _, next := s.Stamp(values); next != seq+1 {
+		t.Fatalf("expect the next write to be %d, got %d", seq+1, next)
+	}
+}
+
+func TestReadViolations(t *testing.T) {
+	ss := newSessions(3)
+	a, b, c := ss[0], ss[1], ss[2]
+	loaded := map[string][]byte{"f": []byte("x")}
+	start := time.Now()
+	expect := func(ryw int64, mr int64, mw int64) {
+		t.Helper()
+		if got := measured("READ_RYW_VIOLATION"); got != ryw {
+			t.Fatalf("expect %d read-your-writes violations, got %d", ryw, got)
+		}
+		if got := measured("READ_MR_VIOLATION"); got != mr {
+			t.Fatalf("expect %d monotonic reads violations, got %d", mr, got)
+		}
+		if got := measured("READ_MW_VIOLATION"); got != mw {
+			t.Fatalf("expect %d monotonic writes violations, got %d", mw, got)
+		}
+	}
+
+	// read-your-writes: a reads the loaded value of k after writing it.
+	written, seq := a.Stamp(loaded)
+	a.Wrote("t", "k", written, seq)
+	a.Read("READ", start, "t", "k", loaded)
+	expect(1, 0, 0)
+	if values := a.Read("READ", start, "t", "k", written); string(values["f"]) != "x" {
+		t.Fatalf("expect the read values without headers, got %q", values)
+	}
+	expect(1, 0, 0)
+
+	// monotonic reads: b reads the second write of a to k2, then the first
+	// one. b observed the second write, so its first one was missed too.
+	first, seq1 := a.Stamp(loaded)
+	a.Wrote("t", "k2", first, seq1)
+	second, seq2 := a.Stamp(loaded)
+	a.Wrote("t", "k2", second, seq2)
+	b.Read("READ", start, "t", "k2", second)
+	expect(1, 0, 0)
+	b.Read("READ", start, "t", "k2", first)
+	expect(1, 1, 1)
+
+	// monotonic writes: c observes the last write of a to k3, then reads
+	// the loaded value of k2, which a wrote before.
+	last, seq3 := a.Stamp(loaded)
+	a.Wrote("t", "k3", last, seq3)
+	c.Read("READ", start, "t", "k3", last)
+	expect(1, 1, 1)
+	c.Read("READ", start, "t", "k2", loaded)
+	expect(1, 1, 2)
+}
@@ -14,47 +14,27 @@
 package util
 
 import (
-	"fmt"
+	"net/url"
 	"sort"
 
 	"github.com/magiconair/properties"
-	"github.com/pingcap/go-ycsb/pkg/prop"
 )
 
-// createFieldIndices is a helper function to create a field -> index mapping
-// for the core workload
-func createFieldIndices(p *properties.Properties) map[string]int64 {
-	fieldCount := p.GetInt64(prop.FieldCount, prop.FieldCountDefault)
-	m := make(map[string]int64, fieldCount)
-	for i := int64(0); i < fieldCount; i++ {
-		field := fmt.Sprintf("field%d", i)
-		m[field] = i
-	}
-	return m
-}
-
-// allFields is a helper function to create all fields
-func allFields(p *properties.Properties) []string {
-	fieldCount := p.GetInt64(prop.FieldCount, prop.FieldCountDefault)
-	fields := make([]string, 0, fieldCount)
-	for i := int64(0); i < fieldCount; i++ {
-		field := fmt.Sprintf("field%d", i)
-		fields = append(fields, field)
-	}
-	return fields
-}
-
 // RowCodec is a helper struct to encode and decode TiDB format row
 type RowCodec struct {
-	fieldIndices map[string]int64
-	fields       []string
+	schema *Schema
+	fields []string
 }
 
 // NewRowCodec creates the RowCodec
 func NewRowCodec(p *properties.Properties) *RowCodec {
+	schema, err := NewSchema(p)
+	if err != nil {
+		Fatalf("bad schema: %v", err)
+	}
 	return &RowCodec{
-		fieldIndices: createFieldIndices(p),
-		fields:       allFields(p),
+		schema: schema,
+		fields: schema.FieldNames(),
 	}
 }
 
@@ -71,7 +51,7 @@ func (r *RowCodec) Decode(row []byte, fields []string) (map[string][]byte, error
 
 	res := make(map[string][]byte, len(fields))
 	for _, field := range fields {
-		i := r.fieldIndices[field]
+		i, _ := r.schema.Index(field)
 		if v, ok := data[i]; ok {
 			res[field] = v
 		}
@@ -80,18 +60,21 @@ func (r *RowCodec) Decode(row []byte, fields []string) (map[string][]byte, error
 	return res, nil
 }
 
-// Encode encodes the values
+// Encode encodes the values, the int64 and float fields of the schema as
+// TiDB int and float values.
 func (r *RowCodec) Encode(buf []byte, values map[string][]byte) ([]byte, error) {
 	cols := make([][]byte, 0, len(values))
 	colIDs := make([]int64, 0, len(values))
+	types := make([]FieldType, 0, len(values))
 
 	for k, v := range values {
-		i := r.fieldIndices[k]
+		i, _ := r.schema.Index(k)
 		cols = append(cols, v)
 		colIDs = append(colIDs, i)
+		types = append(types, r.schema.Type(k))
 	}
 
-	rowData, err := EncodeRow(cols, colIDs, buf)
+	rowData, err := EncodeTypedRow(cols, colIDs, types, buf)
 	return rowData, err
 }
 
@@ -132,3 +115,36 @@ func NewFieldPairs(values map[string][]byte) FieldPairs {
 	sort.Sort(pairs)
 	return pairs
 }
+
+// EncodeFields encodes the values as a text like "field0=a&field1=b+c", with
+// the names and values escaped, for the databases storing a record as one
+// string without spaces or new lines.
+func EncodeFields(values map[string][]byte) string {
+	q := make(url.Values, len(values))
+	for field, value := range values {
+		q.Set(field, string(value))
+	}
+	return q.Encode()
+}
+
+// DecodeFields decodes the values encoded by EncodeFields, only the ones of
+// fields if it is not empty.
+func DecodeFields(s string, fields []string) (map[string][]byte, error) {
+	q, err := url.ParseQuery(s)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string][]byte, len(q))
+	if len(fields) == 0 {
+		for field := range q {
+			values[field] = []byte(q.Get(field))
+		}
+		return values, nil
+	}
+	for _, field := range fields {
+		if v, ok := q[field]; ok && len(v) > 0 {
+			values[field] = []byte(v[0])
+		}
+	}
+	return values, nil
+}
@@ -2,6 +2,7 @@ package util
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -22,3 +23,56 @@ func TestFieldPair(t *testing.T) {
 		t.Errorf("want %v, but got %v", check, p)
 	}
 }
+
+func TestEncodeFields(t *testing.T) {
+	m := map[string][]byte{
+		"f1": []byte("a b\nc"),
+		"f2": []byte("x=y&z%"),
+		"f3": []byte(""),
+	}
+
+	s := EncodeFields(m)
+	if strings.ContainsAny(s, " \n") {
+		t.Fatalf("encoded %q has spaces or new lines", s)
+	}
+	got, err := DecodeFields(s, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, m) {
+		t.Errorf("want %q, but got %q", m, got)
+	}
+
+	got, err = DecodeFields(s, []string{"f2", "f4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string][]byte{"f2": m["f2"]}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %q, but got %q", want, got)
+	}
+}
+
+func TestParseSchema(t *testing.T) {
+	s, err := ParseSchema("id:int64, price:float\n# comment\nname:string:32,doc:JSON")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Field{
+		{Name: "id", Type: FieldInt64},
+		{Name: "price", Type: FieldFloat},
+		{Name: "name", Type: FieldString, Length: 32},
+		{Name: "doc", Type: FieldJSON},
+	}
+	if !reflect.DeepEqual(s.Fields, want) {
+		t.Errorf("want %v, but got %v", want, s.Fields)
+	}
+	if i, ok := s.Index("name"); !ok || i != 2 {
+		t.Errorf("want index 2 of name, but got %d", i)
+	}
+
+	for _, bad := range []string{"", "id", "id:int32", "id:int64,id:bytes", "name:string:0"} {
+		if _, err := ParseSchema(bad); err == nil {
+			t.Errorf("%q: expect an error", bad)
+		}
+	}
+}
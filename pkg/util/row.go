@@ -15,6 +15,8 @@ package util
 
 import (
 	"encoding/binary"
+	"math"
+	"strconv"
 
 	"github.com/pingcap/errors"
 )
@@ -25,6 +27,13 @@ import (
 // EncodeRow will allocate it.
 // It is a simplified and specialized version of `github.com/pingcap/tidb/tablecodec.EncodeRow`.
 func EncodeRow(cols [][]byte, colIDs []int64, valBuf []byte) ([]byte, error) {
+	return EncodeTypedRow(cols, colIDs, nil, valBuf)
+}
+
+// EncodeTypedRow is like EncodeRow, but encodes the int64 and float columns,
+// given in decimal text, as int and float values. A nil types encodes all
+// the columns as bytes.
+func EncodeTypedRow(cols [][]byte, colIDs []int64, types []FieldType, valBuf []byte) ([]byte, error) {
 	if len(cols) != len(colIDs) {
 		return nil, errors.Errorf("EncodeRow error: cols and colIDs count not match %d vs %d", len(cols), len(colIDs))
 	}
@@ -34,16 +43,38 @@ func EncodeRow(cols [][]byte, colIDs []int64, valBuf []byte) ([]byte, error) {
 	}
 	for i := range cols {
 		valBuf = encodeInt64(valBuf, colIDs[i])
-		valBuf = encodeBytes(valBuf, cols[i])
+		if types == nil {
+			valBuf = encodeBytes(valBuf, cols[i])
+			continue
+		}
+		switch types[i] {
+		case FieldInt64:
+			v, err := strconv.ParseInt(string(cols[i]), 10, 64)
+			if err != nil {
+				return nil, errors.Errorf("EncodeRow error: bad int64 value %q of column %d", cols[i], colIDs[i])
+			}
+			valBuf = encodeInt64(valBuf, v)
+		case FieldFloat:
+			v, err := strconv.ParseFloat(string(cols[i]), 64)
+			if err != nil {
+				return nil, errors.Errorf("EncodeRow error: bad float value %q of column %d", cols[i], colIDs[i])
+			}
+			valBuf = encodeFloat64(valBuf, v)
+		default:
+			valBuf = encodeBytes(valBuf, cols[i])
+		}
 	}
 	return valBuf, nil
 }
 
 const (
 	compactBytesFlag byte = 2
+	floatFlag        byte = 5
 	varintFlag       byte = 8
 )
 
+const signMask uint64 = 0x8000000000000000
+
 func encodeInt64(b []byte, v int64) []byte {
 	b = append(b, varintFlag)
 	return appendVarint(b, v)
@@ -55,6 +86,33 @@ func encodeBytes(b []byte, v []byte) []byte {
 	return append(b, v...)
 }
 
+// encodeFloat64 encodes a float in the memcomparable format of TiDB.
+func encodeFloat64(b []byte, v float64) []byte {
+	u := math.Float64bits(v)
+	if v >= 0 {
+		u |= signMask
+	} else {
+		u = ^u
+	}
+	b = append(b, floatFlag)
+	var data [8]byte
+	binary.BigEndian.PutUint64(data[:], u)
+	return append(b, data[:]...)
+}
+
+func decodeFloat64(b []byte) ([]byte, float64, error) {
+	if len(b) < 9 {
+		return nil, 0, errors.New("insufficient bytes to decode value")
+	}
+	u := binary.BigEndian.Uint64(b[1:9])
+	if u&signMask > 0 {
+		u &= ^signMask
+	} else {
+		u = ^u
+	}
+	return b[9:], math.Float64frombits(u), nil
+}
+
 func appendVarint(b []byte, v int64) []byte {
 	var data [binary.MaxVarintLen64]byte
 	n := binary.PutVarint(data[:], v)
@@ -78,7 +136,7 @@ func DecodeRow(b []byte) (map[int64][]byte, error) {
 			return row, err
 		}
 		var v []byte
-		remain, v, err = decodeBytes(remain)
+		remain, v, err = decodeValue(remain)
 		if err != nil {
 			return row, err
 		}
@@ -88,6 +146,29 @@ func DecodeRow(b []byte) (map[int64][]byte, error) {
 	return row, nil
 }
 
+// decodeValue decodes a value, the int and float ones into decimal text.
+func decodeValue(b []byte) ([]byte, []byte, error) {
+	if len(b) == 0 {
+		return nil, nil, errors.New("insufficient bytes to decode value")
+	}
+	switch b[0] {
+	case varintFlag:
+		remain, v, err := decodeInt64(b)
+		if err != nil {
+			return nil, nil, err
+		}
+		return remain, strconv.AppendInt(nil, v, 10), nil
+	case floatFlag:
+		remain, v, err := decodeFloat64(b)
+		if err != nil {
+			return nil, nil, err
+		}
+		return remain, strconv.AppendFloat(nil, v, 'g', -1, 64), nil
+	default:
+		return decodeBytes(b)
+	}
+}
+
 func decodeInt64(b []byte) ([]byte, int64, error) {
 	return decodeVarint(b[1:])
 }
@@ -36,3 +36,27 @@ func TestCodec(t *testing.T) {
 		}
 	}
 }
+
+func TestTypedCodec(t *testing.T) {
+	colIDs := []int64{1, 2, 3, 4, 5}
+	cols := [][]byte{[]byte("42"), []byte("-7"), []byte("0.5"), []byte("-1.25e+10"), []byte("text")}
+	types := []FieldType{FieldInt64, FieldInt64, FieldFloat, FieldFloat, FieldString}
+
+	buf, err := EncodeTypedRow(cols, colIDs, types, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	row, err := DecodeRow(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, id := range colIDs {
+		if !bytes.Equal(cols[i], row[id]) {
+			t.Fatalf("id:%v, before:%q, after:%q", id, cols[i], row[id])
+		}
+	}
+
+	if _, err = EncodeTypedRow([][]byte{[]byte("x")}, []int64{1}, []FieldType{FieldInt64}, nil); err == nil {
+		t.Fatal("expect an error for a bad int64")
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+)
+
+// FieldType is the type of a field. The values of all the types are passed
+// around as bytes, the int64 and float ones in decimal text like "42" and
+// "0.5", so the databases not knowing the schema still store them.
+type FieldType string
+
+// The field types.
+const (
+	FieldInt64  FieldType = "int64"
+	FieldFloat  FieldType = "float"
+	FieldString FieldType = "string"
+	FieldBytes  FieldType = "bytes"
+	FieldJSON   FieldType = "json"
+)
+
+// Field is a field of a schema.
+type Field struct {
+	Name string
+	Type FieldType
+	// Length is the length of the string, bytes and json values, 0 for the
+	// fieldlength of the workload.
+	Length int64
+}
+
+// Schema is the fields of the records.
+type Schema struct {
+	Fields  []Field
+	indices map[string]int64
+}
+
+// NewSchema creates the schema of the properties: the fields of the schema
+// property, or of the file of the schemafile property, or fieldcount bytes
+// fields named field0, field1, ... if neither is set.
+//
+// A schema is a list of fields separated by commas or new lines, a field is
+// name:type or name:type:length, e.g. "id:int64,name:string:32,doc:json".
+// A schema file may have comments starting with #.
+func NewSchema(p *properties.Properties) (*Schema, error) {
+	if s, ok := p.Get(prop.Schema); ok {
+		return ParseSchema(s)
+	}
+	if file, ok := p.Get(prop.SchemaFile); ok {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		return ParseSchema(string(data))
+	}
+
+	fieldCount := p.GetInt64(prop.FieldCount, prop.FieldCountDefault)
+	fields := make([]Field, 0, fieldCount)
+	for i := int64(0); i < fieldCount; i++ {
+		fields = append(fields, Field{Name: fmt.Sprintf("field%d", i), Type: FieldBytes})
+	}
+	return newSchema(fields), nil
+}
+
+// ParseSchema parses a schema.
+func ParseSchema(s string) (*Schema, error) {
+	var fields []Field
+	names := make(map[string]struct{})
+	for _, line := range strings.Split(s, "\n") {
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		for _, entry := range strings.Split(line, ",") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+			parts := strings.Split(entry, ":")
+			if len(parts) < 2 || len(parts) > 3 {
+				return nil, fmt.Errorf("bad field %q, expect name:type or name:type:length", entry)
+			}
+			f := Field{Name: strings.TrimSpace(parts[0]), Type: FieldType(strings.ToLower(strings.TrimSpace(parts[1])))}
+			switch f.Type {
+			case FieldInt64, FieldFloat, FieldString, FieldBytes, FieldJSON:
+			default:
+				return nil, fmt.Errorf("unknown type %s of field %s", f.Type, f.Name)
+			}
+			if len(parts) == 3 {
+				length, err := strconv.ParseInt(strings.TrimSpace(parts[2]), 10, 64)
+				if err != nil || length <= 0 {
+					return nil, fmt.Errorf("bad length %q of field %s", parts[2], f.Name)
+				}
+				f.Length = length
+			}
+			if _, ok := names[f.Name]; ok || f.Name == "" {
+				return nil, fmt.Errorf("duplicate or empty field name %q", f.Name)
+			}
+			names[f.Name] = struct{}{}
+			fields = append(fields, f)
+		}
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no field in the schema")
+	}
+	return newSchema(fields), nil
+}
+
+func newSchema(fields []Field) *Schema {
+	s := &Schema{Fields: fields, indices: make(map[string]int64, len(fields))}
+	for i, f := range fields {
+		s.indices[f.Name] = int64(i)
+	}
+	return s
+}
+
+// FieldNames returns the names of the fields.
+func (s *Schema) FieldNames() []string {
+	names := make([]string, 0, len(s.Fields))
+	for _, f := range s.Fields {
+		names = append(names, f.Name)
+	}
+	return names
+}
+
+// Index returns the index of a field.
+func (s *Schema) Index(name string) (int64, bool) {
+	i, ok := s.indices[name]
+	return i, ok
+}
+
+// Type returns the type of a field, bytes for an unknown one.
+func (s *Schema) Type(name string) FieldType {
+	if i, ok := s.indices[name]; ok {
+		return s.Fields[i].Type
+	}
+	return FieldBytes
+}
+
+// Format formats a value of a field for printing.
+func (s *Schema) Format(name string, v []byte) string {
+	switch s.Type(name) {
+	case FieldInt64, FieldFloat, FieldJSON:
+		return string(v)
+	default:
+		return strconv.Quote(string(v))
+	}
+}
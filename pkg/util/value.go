@@ -0,0 +1,197 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+)
+
+// The content modes of the values.
+const (
+	// ValueModeRandom fills values with random letters.
+	ValueModeRandom = "random"
+	// ValueModeText fills values with words, like a natural language text.
+	ValueModeText = "text"
+	// ValueModeRepeated repeats a short random pattern.
+	ValueModeRepeated = "repeated"
+	// ValueModeJSON fills values with a JSON-like object of words, numbers
+	// and booleans, cut at the value length.
+	ValueModeJSON = "json"
+	// ValueModeZero fills values with zero bytes.
+	ValueModeZero = "zero"
+)
+
+// repeatedPatternLen is the length of the pattern of ValueModeRepeated.
+const repeatedPatternLen = 8
+
+var words = []string{
+	"the", "of", "and", "to", "in", "is", "for", "that", "with", "on",
+	"as", "by", "at", "from", "data", "value", "time", "user", "order", "item",
+	"system", "server", "table", "record", "request", "service", "account", "number",
+	"status", "update", "region", "store", "client", "cluster", "version", "latency",
+	"replica", "leader", "commit", "transaction", "index", "query", "result", "error",
+	"network", "storage", "memory", "engine", "node", "write", "read", "key",
+}
+
+// ValueGenerator fills values with the content of a mode. Like db_bench,
+// it generates the first compression ratio of a value and repeats it, so a
+// compressor shrinks the value to about the ratio of its size.
+type ValueGenerator struct {
+	mode  string
+	ratio float64
+}
+
+// NewValueGenerator creates a ValueGenerator of mode, with a compression
+// ratio in (0, 1], 1 leaving the compressibility to the mode.
+func NewValueGenerator(mode string, ratio float64) (*ValueGenerator, error) {
+	switch mode {
+	case ValueModeRandom, ValueModeText, ValueModeRepeated, ValueModeJSON, ValueModeZero:
+	default:
+		return nil, fmt.Errorf("unknown value mode %s", mode)
+	}
+	if ratio <= 0 || ratio > 1 {
+		return nil, fmt.Errorf("compression ratio %v must be in (0, 1]", ratio)
+	}
+	return &ValueGenerator{mode: mode, ratio: ratio}, nil
+}
+
+// Fill fills b with the random content of the mode. The same source state
+// fills the same content, so a value can be rebuilt to verify it.
+func (g *ValueGenerator) Fill(r rand.Source, b []byte) {
+	if g.mode == ValueModeZero {
+		for i := range b {
+			b[i] = 0
+		}
+		return
+	}
+
+	raw := int(math.Ceil(float64(len(b)) * g.ratio))
+	if raw == 0 {
+		return
+	}
+	switch g.mode {
+	case ValueModeRandom:
+		fillLetters(r, b[:raw])
+	case ValueModeText:
+		fillText(r, b[:raw])
+	case ValueModeRepeated:
+		if raw > repeatedPatternLen {
+			raw = repeatedPatternLen
+		}
+		fillLetters(r, b[:raw])
+	case ValueModeJSON:
+		fillJSON(r, b[:raw])
+	}
+	for i := raw; i < len(b); i += raw {
+		copy(b[i:], b[:raw])
+	}
+}
+
+// fillLetters fills b with random letters, taking 6 bits of randomness per letter.
+func fillLetters(r rand.Source, b []byte) {
+	var bits int64
+	n := 0
+	for i := 0; i < len(b); {
+		if n < 6 {
+			bits, n = r.Int63(), 63
+		}
+		idx := int(bits & 63)
+		bits >>= 6
+		n -= 6
+		if idx < len(letters) {
+			b[i] = letters[idx]
+			i++
+		}
+	}
+}
+
+// nextWord returns a random word, the first words are more frequent like
+// in a natural language.
+func nextWord(r rand.Source) string {
+	v := r.Int63()
+	i, j := int(v%int64(len(words))), int((v>>32)%int64(len(words)))
+	if j < i {
+		i = j
+	}
+	return words[i]
+}
+
+func fillText(r rand.Source, b []byte) {
+	buf := b[:0:len(b)]
+	for i := 0; len(buf) < len(b); i++ {
+		if i > 0 {
+			if r.Int63()%10 == 0 {
+				buf = append(buf, '.')
+			}
+			buf = append(buf, ' ')
+		}
+		buf = append(buf, nextWord(r)...)
+	}
+	// append may have grown into a new array.
+	copy(b, buf)
+}
+
+func fillJSON(r rand.Source, b []byte) {
+	buf := append(b[:0:len(b)], '{')
+	for i := 0; len(buf) < len(b); i++ {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, '"')
+		buf = append(buf, nextWord(r)...)
+		buf = append(buf, '"', ':')
+		switch v := r.Int63(); v % 3 {
+		case 0:
+			buf = append(buf, '"')
+			buf = append(buf, nextWord(r)...)
+			buf = append(buf, ' ')
+			buf = append(buf, nextWord(r)...)
+			buf = append(buf, '"')
+		case 1:
+			buf = strconv.AppendInt(buf, (v>>2)%100000, 10)
+		default:
+			buf = strconv.AppendBool(buf, v&4 == 0)
+		}
+	}
+	copy(b, buf)
+	if len(b) > 1 {
+		b[len(b)-1] = '}'
+	}
+}
+
+// SplitMix64 is a small and fast rand.Source, cheap to create for every
+// value built from a seed.
+type SplitMix64 uint64
+
+// Seed implements the rand.Source Seed interface.
+func (s *SplitMix64) Seed(seed int64) {
+	*s = SplitMix64(seed)
+}
+
+// Uint64 implements the rand.Source64 Uint64 interface.
+func (s *SplitMix64) Uint64() uint64 {
+	*s += 0x9e3779b97f4a7c15
+	z := uint64(*s)
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// Int63 implements the rand.Source Int63 interface.
+func (s *SplitMix64) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
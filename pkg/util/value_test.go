@@ -0,0 +1,74 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"compress/flate"
+	"testing"
+)
+
+func compressedRatio(t *testing.T, b []byte) float64 {
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.DefaultCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(b)
+	w.Close()
+	return float64(buf.Len()) / float64(len(b))
+}
+
+func TestValueGenerator(t *testing.T) {
+	for _, mode := range []string{ValueModeRandom, ValueModeText, ValueModeRepeated, ValueModeJSON, ValueModeZero} {
+		g, err := NewValueGenerator(mode, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, size := range []int{0, 1, 7, 100, 4096} {
+			a, b := make([]byte, size), make([]byte, size)
+			seedA, seedB := SplitMix64(42), SplitMix64(42)
+			g.Fill(&seedA, a)
+			g.Fill(&seedB, b)
+			if !bytes.Equal(a, b) {
+				t.Fatalf("%s: the same seed fills %q and %q", mode, a, b)
+			}
+		}
+	}
+
+	if _, err := NewValueGenerator("unknown", 1); err == nil {
+		t.Fatal("expect an error for an unknown mode")
+	}
+	if _, err := NewValueGenerator(ValueModeRandom, 0); err == nil {
+		t.Fatal("expect an error for a zero ratio")
+	}
+}
+
+func TestValueGeneratorRatio(t *testing.T) {
+	var last float64
+	for _, ratio := range []float64{0.1, 0.5, 1} {
+		g, _ := NewValueGenerator(ValueModeRandom, ratio)
+		b := make([]byte, 16384)
+		seed := SplitMix64(1)
+		g.Fill(&seed, b)
+		got := compressedRatio(t, b)
+		if got > ratio+0.05 {
+			t.Fatalf("ratio %v: compressed to %.3f", ratio, got)
+		}
+		if got <= last {
+			t.Fatalf("ratio %v: compressed to %.3f, not more than %.3f", ratio, got, last)
+		}
+		last = got
+	}
+}
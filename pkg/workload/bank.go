@@ -0,0 +1,501 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+const (
+	bankStateKey     = contextKey("bank")
+	bankBalanceField = "balance"
+)
+
+type bankState struct {
+	// db is the DB of the thread, set by its first transaction.
+	db ycsb.DB
+	// txnDB is db if the DB it wraps supports transactions.
+	txnDB ycsb.TxnDB
+}
+
+// bankWorkload is a closed economy: it loads the accounts with the same
+// balance and then transfers money between them, so the total balance must
+// never change. The transfers run in one transaction if the DB implements
+// TxnDB, and as plain reads and updates otherwise, which shows the anomalies
+// of a non-transactional store.
+//
+// Besides the total, a client-side ledger tracks the expected balance of
+// every account from the successful writes, so the check can point at the
+// offending accounts. The ledger assumes the run phase starts right after
+// a fresh load.
+type bankWorkload struct {
+	*core
+
+	accountStart   int64
+	accountCount   int64
+	initialBalance int64
+	maxTransfer    int64
+	maxRetries     int64
+	checkInterval  time.Duration
+
+	// ledger holds the expected balance of every account.
+	ledger []int64
+	// unknown holds the accounts written by a transaction whose commit
+	// outcome is unknown, their balances can't be predicted.
+	unknown sync.Map
+	// nextCheck is the time in unix nanoseconds of the next periodic check.
+	nextCheck int64
+	// runningThreads is the number of threads not cleaned up yet, the last
+	// one runs the final check. The first thread to start sets it, the
+	// threads may start and stop in any order.
+	runningThreads int64
+	threadsStarted int32
+	// db is the DB of the first transaction, saved for the final check as
+	// the last thread to stop may have run none.
+	db     ycsb.DB
+	saveDB sync.Once
+}
+
+// InitThread implements the Workload InitThread interface.
+func (b *bankWorkload) InitThread(ctx context.Context, threadID int, threadCount int) context.Context {
+	if atomic.CompareAndSwapInt32(&b.threadsStarted, 0, 1) {
+		atomic.StoreInt64(&b.runningThreads, int64(threadCount))
+	}
+	ctx = b.core.InitThread(ctx, threadID, threadCount)
+	return context.WithValue(ctx, bankStateKey, new(bankState))
+}
+
+// CleanupThread implements the Workload CleanupThread interface.
+func (b *bankWorkload) CleanupThread(ctx context.Context) {
+	if atomic.AddInt64(&b.runningThreads, -1) != 0 {
+		return
+	}
+	atomic.StoreInt32(&b.threadsStarted, 0)
+
+	// Only the run phase saves the DB.
+	if b.db == nil {
+		return
+	}
+	if err := b.check(detachedContext{ctx}, b.db, true); err != nil {
+		fmt.Printf("bank final check failed: %v\n", err)
+	}
+}
+
+// Verify implements the VerifyWorkload Verify interface, an account is
+// corrupted if it has no valid balance.
+func (b *bankWorkload) Verify(ctx context.Context, dbs []ycsb.DB) (*ycsb.VerifyReport, error) {
+	return b.verify(ctx, dbs, func(key string, values map[string][]byte) bool {
+		_, err := parseBalance(key, values)
+		return err != nil
+	})
+}
+
+func (b *bankWorkload) accountKey(i int64) string {
+	return b.buildKeyName(b.accountStart + i)
+}
+
+// DoInsert implements the Workload DoInsert interface.
+func (b *bankWorkload) DoInsert(ctx context.Context, db ycsb.DB) error {
+	state := ctx.Value(stateKey).(*coreState)
+	keyNum := b.keySequence.Next(state.r)
+	return db.Insert(ctx, b.table, b.buildKeyName(keyNum), balanceValues(b.initialBalance))
+}
+
+// DoBatchInsert implements the Workload DoBatchInsert interface.
+func (b *bankWorkload) DoBatchInsert(ctx context.Context, batchSize int, db ycsb.DB) error {
+	batchDB, ok := db.(ycsb.BatchDB)
+	if !ok {
+		return fmt.Errorf("the %T does't implement the batchDB interface", db)
+	}
+	state := ctx.Value(stateKey).(*coreState)
+
+	keys := make([]string, 0, batchSize)
+	values := make([]map[string][]byte, 0, batchSize)
+	for i := 0; i < batchSize; i++ {
+		keys = append(keys, b.buildKeyName(b.keySequence.Next(state.r)))
+		values = append(values, balanceValues(b.initialBalance))
+	}
+	return batchDB.BatchInsert(ctx, b.table, keys, values)
+}
+
+// DoTransaction implements the Workload DoTransaction interface.
+func (b *bankWorkload) DoTransaction(ctx context.Context, db ycsb.DB) error {
+	bs := ctx.Value(bankStateKey).(*bankState)
+	if bs.db == nil {
+		bs.db = db
+		bs.txnDB = asTxnDB(db)
+		b.saveDB.Do(func() { b.db = db })
+	}
+
+	txnDB := bs.txnDB
+	if txnDB != nil && b.checkDue() {
+		return b.check(ctx, db, false)
+	}
+
+	state := ctx.Value(stateKey).(*coreState)
+	from, to := b.nextAccounts(state)
+	amount := 1 + state.r.Int63n(b.maxTransfer)
+
+	if txnDB == nil {
+		start := time.Now()
+		err := b.transfer(ctx, db, from, to, amount)
+		if err == nil {
+			measurement.Measure("TRANSFER", start, time.Now().Sub(start))
+		}
+		return err
+	}
+
+	start := time.Now()
+	for retry := int64(0); ; retry++ {
+		txn, err := txnDB.Begin(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = b.transferInTxn(ctx, txn, from, to, amount)
+		if err == nil {
+			measurement.Measure("TRANSFER", start, time.Now().Sub(start))
+			return nil
+		}
+		if !errors.Is(err, ycsb.ErrTxnConflict) {
+			return err
+		}
+
+		measurement.Measure("TRANSFER_CONFLICT", start, time.Now().Sub(start))
+		if retry >= b.maxRetries {
+			measurement.Measure("TRANSFER_ABORT", start, time.Now().Sub(start))
+			return nil
+		}
+	}
+}
+
+// DoBatchTransaction implements the Workload DoBatchTransaction interface.
+func (b *bankWorkload) DoBatchTransaction(ctx context.Context, batchSize int, db ycsb.DB) error {
+	for i := 0; i < batchSize; i++ {
+		if err := b.DoTransaction(ctx, db); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkDue reports whether the caller should run the periodic check.
+func (b *bankWorkload) checkDue() bool {
+	if b.checkInterval <= 0 {
+		return false
+	}
+	now := time.Now().UnixNano()
+	next := atomic.LoadInt64(&b.nextCheck)
+	if now < next {
+		return false
+	}
+	return atomic.CompareAndSwapInt64(&b.nextCheck, next, now+int64(b.checkInterval))
+}
+
+// nextAccounts returns two distinct accounts chosen by the request distribution.
+func (b *bankWorkload) nextAccounts(state *coreState) (int64, int64) {
+	next := func() int64 {
+		i := (b.nextKeyNum(state) - b.accountStart) % b.accountCount
+		if i < 0 {
+			i += b.accountCount
+		}
+		return i
+	}
+
+	from := next()
+	to := next()
+	for from == to {
+		to = next()
+	}
+	return from, to
+}
+
+func parseBalance(key string, values map[string][]byte) (int64, error) {
+	value, ok := values[bankBalanceField]
+	if !ok {
+		return 0, fmt.Errorf("account %s not found", key)
+	}
+	balance, err := strconv.ParseInt(string(value), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("account %s has an invalid balance %q", key, value)
+	}
+	return balance, nil
+}
+
+func balanceValues(balance int64) map[string][]byte {
+	return map[string][]byte{
+		bankBalanceField: []byte(strconv.FormatInt(balance, 10)),
+	}
+}
+
+// transfer moves at most amount from one account to another with plain
+// reads and updates, the ledger follows every successful update.
+func (b *bankWorkload) transfer(ctx context.Context, db ycsb.DB, from int64, to int64, amount int64) error {
+	fromKey, toKey := b.accountKey(from), b.accountKey(to)
+	fields := []string{bankBalanceField}
+
+	values, err := db.Read(ctx, b.table, fromKey, fields)
+	if err != nil {
+		return err
+	}
+	fromBalance, err := parseBalance(fromKey, values)
+	if err != nil {
+		return err
+	}
+	values, err = db.Read(ctx, b.table, toKey, fields)
+	if err != nil {
+		return err
+	}
+	toBalance, err := parseBalance(toKey, values)
+	if err != nil {
+		return err
+	}
+
+	if amount > fromBalance {
+		amount = fromBalance
+	}
+	if amount <= 0 {
+		return nil
+	}
+
+	if err := db.Update(ctx, b.table, fromKey, balanceValues(fromBalance-amount)); err != nil {
+		return err
+	}
+	atomic.AddInt64(&b.ledger[from], -amount)
+	if err := db.Update(ctx, b.table, toKey, balanceValues(toBalance+amount)); err != nil {
+		return err
+	}
+	atomic.AddInt64(&b.ledger[to], amount)
+	return nil
+}
+
+// transferInTxn moves at most amount from one account to another in txn,
+// and commits or rolls back txn.
+func (b *bankWorkload) transferInTxn(ctx context.Context, txn ycsb.Txn, from int64, to int64, amount int64) (err error) {
+	committing := false
+	defer func() {
+		if err != nil && !committing {
+			txn.Rollback(ctx)
+		}
+	}()
+
+	fromKey, toKey := b.accountKey(from), b.accountKey(to)
+	fields := []string{bankBalanceField}
+
+	values, err := txn.Read(ctx, b.table, fromKey, fields)
+	if err != nil {
+		return err
+	}
+	fromBalance, err := parseBalance(fromKey, values)
+	if err != nil {
+		return err
+	}
+	values, err = txn.Read(ctx, b.table, toKey, fields)
+	if err != nil {
+		return err
+	}
+	toBalance, err := parseBalance(toKey, values)
+	if err != nil {
+		return err
+	}
+
+	if amount > fromBalance {
+		amount = fromBalance
+	}
+	if amount <= 0 {
+		return txn.Rollback(ctx)
+	}
+
+	if err = txn.Update(ctx, b.table, fromKey, balanceValues(fromBalance-amount)); err != nil {
+		return err
+	}
+	if err = txn.Update(ctx, b.table, toKey, balanceValues(toBalance+amount)); err != nil {
+		return err
+	}
+
+	committing = true
+	err = txn.Commit(ctx)
+	switch {
+	case err == nil:
+		atomic.AddInt64(&b.ledger[from], -amount)
+		atomic.AddInt64(&b.ledger[to], amount)
+	case !errors.Is(err, ycsb.ErrTxnConflict):
+		b.unknown.Store(from, struct{}{})
+		b.unknown.Store(to, struct{}{})
+	}
+	return err
+}
+
+// asTxnDB returns db as a TxnDB if the DB it wraps supports transactions,
+// or nil.
+func asTxnDB(db ycsb.DB) ycsb.TxnDB {
+	if !ycsb.Supports(db, (*ycsb.TxnDB)(nil)) {
+		return nil
+	}
+	txnDB, _ := db.(ycsb.TxnDB)
+	return txnDB
+}
+
+// readBalances reads the balances of all the accounts, in one transaction
+// if db supports transactions.
+func (b *bankWorkload) readBalances(ctx context.Context, db ycsb.DB) ([]int64, error) {
+	read := db.Read
+	if txnDB := asTxnDB(db); txnDB != nil {
+		txn, err := txnDB.Begin(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer txn.Rollback(ctx)
+		read = txn.Read
+	}
+
+	fields := []string{bankBalanceField}
+	balances := make([]int64, b.accountCount)
+	for i := range balances {
+		key := b.accountKey(int64(i))
+		values, err := read(ctx, b.table, key, fields)
+		if err != nil {
+			return nil, err
+		}
+		if balances[i], err = parseBalance(key, values); err != nil {
+			return nil, err
+		}
+	}
+	return balances, nil
+}
+
+// check verifies that the total balance is unchanged. On a violation, or on
+// the final check, it also reports the accounts whose balance differs from
+// the ledger. Transfers in flight during a periodic check may make the
+// ledger lag behind the database, so the accounts reported then are suspects.
+func (b *bankWorkload) check(ctx context.Context, db ycsb.DB, final bool) error {
+	start := time.Now()
+	balances, err := b.readBalances(ctx, db)
+	if err != nil {
+		return err
+	}
+	measurement.Measure("BANK_CHECK", start, time.Now().Sub(start))
+
+	var total int64
+	for _, balance := range balances {
+		total += balance
+	}
+	expectedTotal := b.accountCount * b.initialBalance
+
+	var mismatches []string
+	var unknown []string
+	if final || total != expectedTotal {
+		mismatches, unknown = b.compareLedger(balances)
+	}
+
+	name := "periodic"
+	if final {
+		name = "final"
+	}
+	if total == expectedTotal && len(mismatches) == 0 {
+		fmt.Printf("bank %s check passed: total balance %d over %d accounts\n", name, total, b.accountCount)
+		return nil
+	}
+
+	measurement.Measure("BANK_VIOLATION", start, time.Now().Sub(start))
+	fmt.Printf("bank %s check failed: total balance %d, expected %d\n", name, total, expectedTotal)
+	if len(mismatches) > 0 {
+		fmt.Printf("bank accounts differing from the ledger: %v\n", mismatches)
+	}
+	if len(unknown) > 0 {
+		fmt.Printf("bank accounts written by transactions with an unknown outcome: %v\n", unknown)
+	}
+	return nil
+}
+
+// compareLedger returns the accounts whose balance differs from the ledger,
+// and the ones with an unknown balance.
+func (b *bankWorkload) compareLedger(balances []int64) ([]string, []string) {
+	var mismatches []string
+	var unknown []string
+	for i, balance := range balances {
+		key := b.accountKey(int64(i))
+		if _, ok := b.unknown.Load(int64(i)); ok {
+			unknown = append(unknown, key)
+			continue
+		}
+		if expected := atomic.LoadInt64(&b.ledger[i]); balance != expected {
+			mismatches = append(mismatches, fmt.Sprintf("%s(balance %d, expected %d)", key, balance, expected))
+		}
+	}
+	sort.Strings(mismatches)
+	sort.Strings(unknown)
+	return mismatches, unknown
+}
+
+// detachedContext keeps the values of the parent context but is never
+// canceled, so the final check still runs after the run phase times out.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+type bankWorkloadCreator struct {
+}
+
+// Create implements the WorkloadCreator Create interface.
+func (bankWorkloadCreator) Create(p *properties.Properties) (ycsb.Workload, error) {
+	w, err := coreCreator{}.Create(p)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &bankWorkload{
+		core:           w.(*core),
+		initialBalance: p.GetInt64(prop.BankInitialBalance, prop.BankInitialBalanceDefault),
+		maxTransfer:    p.GetInt64(prop.BankMaxTransfer, prop.BankMaxTransferDefault),
+		maxRetries:     p.GetInt64(prop.TxnMaxRetries, prop.TxnMaxRetriesDefault),
+		checkInterval:  p.GetParsedDuration(prop.BankCheckInterval, 0),
+	}
+	b.accountStart = p.GetInt64(prop.InsertStart, prop.InsertStartDefault)
+	b.accountCount = p.GetInt64(prop.InsertCount, b.recordCount-b.accountStart)
+	if b.accountCount < 2 {
+		util.Fatalf("bank workload needs at least 2 accounts, but got %d", b.accountCount)
+	}
+	if b.initialBalance < 0 || b.maxTransfer <= 0 {
+		util.Fatalf("%s must not be negative and %s must be positive", prop.BankInitialBalance, prop.BankMaxTransfer)
+	}
+
+	b.ledger = make([]int64, b.accountCount)
+	for i := range b.ledger {
+		b.ledger[i] = b.initialBalance
+	}
+	b.nextCheck = time.Now().Add(b.checkInterval).UnixNano()
+	return b, nil
+}
+
+func init() {
+	ycsb.RegisterWorkloadCreator("bank", bankWorkloadCreator{})
+}
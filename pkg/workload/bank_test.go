@@ -0,0 +1,213 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/client"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+)
+
+// memDB is a plain in-memory DB, it implements none of the optional
+// interfaces.
+type memDB struct {
+	sync.Mutex
+	rows map[string]map[string][]byte
+}
+
+func newMemDB() *memDB {
+	return &memDB{rows: make(map[string]map[string][]byte)}
+}
+
+func (db *memDB) Close() error {
+	return nil
+}
+
+func (db *memDB) InitThread(ctx context.Context, _ int, _ int) context.Context {
+	return ctx
+}
+
+func (db *memDB) CleanupThread(_ context.Context) {
+}
+
+func (db *memDB) Read(_ context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	db.Lock()
+	defer db.Unlock()
+	row, ok := db.rows[table+"/"+key]
+	if !ok {
+		return nil, nil
+	}
+	values := make(map[string][]byte, len(row))
+	for field, value := range row {
+		values[field] = value
+	}
+	if len(fields) > 0 {
+		for field := range values {
+			if !containsField(fields, field) {
+				delete(values, field)
+			}
+		}
+	}
+	return values, nil
+}
+
+func containsField(fields []string, field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+func (db *memDB) Scan(_ context.Context, _ string, _ string, _ int, _ []string) ([]map[string][]byte, error) {
+	return nil, nil
+}
+
+func (db *memDB) Update(_ context.Context, table string, key string, values map[string][]byte) error {
+	db.Lock()
+	defer db.Unlock()
+	row, ok := db.rows[table+"/"+key]
+	if !ok {
+		row = make(map[string][]byte)
+		db.rows[table+"/"+key] = row
+	}
+	for field, value := range values {
+		row[field] = append([]byte(nil), value...)
+	}
+	return nil
+}
+
+func (db *memDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	return db.Update(ctx, table, key, values)
+}
+
+func (db *memDB) Delete(_ context.Context, table string, key string) error {
+	db.Lock()
+	defer db.Unlock()
+	delete(db.rows, table+"/"+key)
+	return nil
+}
+
+func TestBankWithoutTxnDB(t *testing.T) {
+	p := properties.MustLoadString("recordcount=10\noperationcount=200\nbank.checkinterval=1ms")
+	measurement.InitMeasure(p)
+	w, err := bankWorkloadCreator{}.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := w.(*bankWorkload)
+	db := client.DbWrapper{DB: newMemDB()}
+
+	ctx := b.InitThread(context.Background(), 0, 1)
+	for i := int64(0); i < b.accountCount; i++ {
+		if err := b.DoInsert(ctx, db); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b.CleanupThread(ctx)
+
+	ctx = b.InitThread(context.Background(), 0, 1)
+	for i := 0; i < 200; i++ {
+		if err := b.DoTransaction(ctx, db); err != nil {
+			t.Fatalf("transfer %d: %v", i, err)
+		}
+	}
+	if state := ctx.Value(bankStateKey).(*bankState); state.txnDB != nil {
+		t.Fatal("the transfers of a plain DB must not run in transactions")
+	}
+
+	balances, err := b.readBalances(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var total int64
+	for i, balance := range balances {
+		total += balance
+		if balance != b.ledger[i] {
+			t.Fatalf("account %d has balance %d, expected %d", i, balance, b.ledger[i])
+		}
+	}
+	if expected := b.accountCount * b.initialBalance; total != expected {
+		t.Fatalf("total balance %d, expected %d", total, expected)
+	}
+}
+
+// countingDB counts the reads of a memDB.
+type countingDB struct {
+	*memDB
+	reads int64
+}
+
+func (db *countingDB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	atomic.AddInt64(&db.reads, 1)
+	return db.memDB.Read(ctx, table, key, fields)
+}
+
+func TestBankCheck(t *testing.T) {
+	p := properties.MustLoadString("recordcount=10\noperationcount=50")
+	measurement.InitMeasure(p)
+	w, err := bankWorkloadCreator{}.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := w.(*bankWorkload)
+	counting := &countingDB{memDB: newMemDB()}
+	db := client.DbWrapper{DB: counting}
+
+	ctx := b.InitThread(context.Background(), 0, 1)
+	for i := int64(0); i < b.accountCount; i++ {
+		if err := b.DoInsert(ctx, db); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b.CleanupThread(ctx)
+
+	// only the first of the two threads runs transfers.
+	ctx = b.InitThread(context.Background(), 0, 2)
+	idle := b.InitThread(context.Background(), 1, 2)
+	for i := 0; i < 50; i++ {
+		if err := b.DoTransaction(ctx, db); err != nil {
+			t.Fatalf("transfer %d: %v", i, err)
+		}
+	}
+
+	// the check points at the account corrupted behind the ledger.
+	corrupted := b.accountKey(3)
+	if err := db.Update(ctx, b.table, corrupted, balanceValues(b.ledger[3]+7)); err != nil {
+		t.Fatal(err)
+	}
+	balances, err := b.readBalances(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mismatches, unknown := b.compareLedger(balances)
+	if len(mismatches) != 1 || !strings.HasPrefix(mismatches[0], corrupted+"(") || len(unknown) != 0 {
+		t.Fatalf("expect only %s to differ from the ledger, got %v", corrupted, mismatches)
+	}
+
+	// the idle thread stops last and still runs the final check.
+	b.CleanupThread(ctx)
+	reads := atomic.LoadInt64(&counting.reads)
+	b.CleanupThread(idle)
+	if checked := atomic.LoadInt64(&counting.reads) - reads; checked != b.accountCount {
+		t.Fatalf("expect the final check to read the %d accounts, got %d reads", b.accountCount, checked)
+	}
+}
@@ -16,12 +16,14 @@ package workload
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"math/rand"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/magiconair/properties"
@@ -40,6 +42,11 @@ type coreState struct {
 	r *rand.Rand
 	// fieldNames is a copy of core.fieldNames to be goroutine-local
 	fieldNames []string
+	// keyChooser is the key chooser of the thread, if the distribution
+	// depends on the thread.
+	keyChooser ycsb.Generator
+	// session is the session of the virtual user of the current operation.
+	session *ycsb.UserSession
 }
 
 type operationType int64
@@ -50,8 +57,44 @@ const (
 	insert
 	scan
 	readModifyWrite
+	remove
+	batchRead
+	batchUpdate
+	batchInsert
+	batchScan
+	batchReadModifyWrite
+	batchRemove
+	compareAndSwap
 )
 
+// batchOperations holds the properties of the batch operations mixed with
+// the single ones.
+var batchOperations = []struct {
+	op         operationType
+	proportion string
+	size       string
+}{
+	{batchRead, prop.BatchReadProportion, prop.BatchReadSize},
+	{batchUpdate, prop.BatchUpdateProportion, prop.BatchUpdateSize},
+	{batchInsert, prop.BatchInsertProportion, prop.BatchInsertSize},
+	{batchScan, prop.BatchScanProportion, prop.BatchScanSize},
+	{batchReadModifyWrite, prop.BatchReadModifyWriteProportion, prop.BatchReadModifyWriteSize},
+	{batchRemove, prop.BatchDeleteProportion, prop.BatchDeleteSize},
+}
+
+// maxBatchOpSize is the upper bound of the batch size distributions without
+// their own range.
+const maxBatchOpSize = 100
+
+// coreField is how the values of a field are generated.
+type coreField struct {
+	typ    util.FieldType
+	length ycsb.Generator
+	value  *util.ValueGenerator
+	// json is set for the values generated in the json mode.
+	json bool
+}
+
 // Core is the core benchmark scenario. Represents a set of clients doing simple CRUD operations.
 type core struct {
 	p *properties.Properties
@@ -65,25 +108,48 @@ type core struct {
 	writeAllFields       bool
 	dataIntegrity        bool
 
+	// fields holds how the values of every field are generated.
+	fields       map[string]*coreField
+	defaultField *coreField
+	keyFormat    *keyFormat
+
 	keySequence                  ycsb.Generator
 	operationChooser             *generator.Discrete
 	keyChooser                   ycsb.Generator
+	threadKeyChooser             func(threadID int, threadCount int) ycsb.Generator
 	fieldChooser                 ycsb.Generator
 	transactionInsertKeySequence *generator.AcknowledgedCounter
 	scanLength                   ycsb.Generator
-	orderedInserts               bool
 	recordCount                  int64
-	zeroPadding                  int64
 	insertionRetryLimit          int64
 	insertionRetryInterval       int64
+	deleteOldest                 bool
+	// oldestKey is the next key to delete when deleting the oldest records.
+	oldestKey int64
+	// deletedKeys holds the deleted keys, whose misses are expected. The
+	// oldest keys only stay in it until the keys before them are deleted too,
+	// then they are below deletedBelow, unless their delete failed and they
+	// are in undeletedKeys.
+	deletedKeys   util.ConcurrentMap
+	deleteLock    sync.Mutex
+	deletedBelow  int64
+	undeletedKeys util.ConcurrentMap
+	// insertStart and insertCount are the range of the loaded records.
+	insertStart int64
+	insertCount int64
+	// sessionKeys is the number of related records accessed by a session.
+	sessionKeys int64
+	// batchSizes holds the batch size generators of the batch operations
+	// in the operation mix.
+	batchSizes map[operationType]ycsb.Generator
+	// doCAS is whether the run phase mixes in compare-and-swaps.
+	doCAS bool
 
 	valuePool sync.Pool
 }
 
-func getFieldLengthGenerator(p *properties.Properties) ycsb.Generator {
+func getFieldLengthGenerator(p *properties.Properties, fieldLengthDistribution string, fieldLength int64) ycsb.Generator {
 	var fieldLengthGenerator ycsb.Generator
-	fieldLengthDistribution := p.GetString(prop.FieldLengthDistribution, prop.FieldLengthDistributionDefault)
-	fieldLength := p.GetInt64(prop.FieldLength, prop.FieldLengthDefault)
 	fieldLengthHistogram := p.GetString(prop.FieldLengthHistogramFile, prop.FieldLengthHistogramFileDefault)
 
 	switch strings.ToLower(fieldLengthDistribution) {
@@ -96,7 +162,12 @@ func getFieldLengthGenerator(p *properties.Properties) ycsb.Generator {
 	case "histogram":
 		fieldLengthGenerator = generator.NewHistogramFromFile(fieldLengthHistogram)
 	default:
-		util.Fatalf("unknown field length distribution %s", fieldLengthDistribution)
+		gen, err := generator.ParseSpec(fieldLengthDistribution, 1, fieldLength)
+		if err != nil {
+			util.Fatalf("unknown field length distribution %s: %v", fieldLengthDistribution, err)
+		}
+		// a negative length can't be generated
+		fieldLengthGenerator = generator.NewClamp(gen, 0, math.MaxInt32)
 	}
 
 	return fieldLengthGenerator
@@ -108,6 +179,7 @@ func createOperationGenerator(p *properties.Properties) *generator.Discrete {
 	insertProportion := p.GetFloat64(prop.InsertProportion, prop.InsertProportionDefault)
 	scanProportion := p.GetFloat64(prop.ScanProportion, prop.ScanProportionDefault)
 	readModifyWriteProportion := p.GetFloat64(prop.ReadModifyWriteProportion, prop.ReadModifyWriteProportionDefault)
+	deleteProportion := p.GetFloat64(prop.DeleteProportion, prop.DeleteProportionDefault)
 
 	operationChooser := generator.NewDiscrete()
 	if readProportion > 0 {
@@ -130,6 +202,20 @@ func createOperationGenerator(p *properties.Properties) *generator.Discrete {
 		operationChooser.Add(readModifyWriteProportion, int64(readModifyWrite))
 	}
 
+	if deleteProportion > 0 {
+		operationChooser.Add(deleteProportion, int64(remove))
+	}
+
+	if casProportion := p.GetFloat64(prop.CASProportion, prop.CASProportionDefault); casProportion > 0 {
+		operationChooser.Add(casProportion, int64(compareAndSwap))
+	}
+
+	for _, b := range batchOperations {
+		if proportion := p.GetFloat64(b.proportion, 0); proportion > 0 {
+			operationChooser.Add(proportion, int64(b.op))
+		}
+	}
+
 	return operationChooser
 }
 
@@ -139,7 +225,11 @@ func (c *core) Load(ctx context.Context, db ycsb.DB, totalCount int64) error {
 }
 
 // InitThread implements the Workload InitThread interface.
-func (c *core) InitThread(ctx context.Context, _ int, _ int) context.Context {
+func (c *core) InitThread(ctx context.Context, threadID int, threadCount int) context.Context {
+	return context.WithValue(ctx, stateKey, c.newState(threadID, threadCount))
+}
+
+func (c *core) newState(threadID int, threadCount int) *coreState {
 	r := rand.New(rand.NewSource(time.Now().UnixNano()))
 	fieldNames := make([]string, len(c.fieldNames))
 	copy(fieldNames, c.fieldNames)
@@ -147,7 +237,18 @@ func (c *core) InitThread(ctx context.Context, _ int, _ int) context.Context {
 		r:          r,
 		fieldNames: fieldNames,
 	}
-	return context.WithValue(ctx, stateKey, state)
+	if c.threadKeyChooser != nil {
+		state.keyChooser = c.threadKeyChooser(threadID, threadCount)
+	}
+	return state
+}
+
+// CheckDB implements the DBCheckWorkload CheckDB interface.
+func (c *core) CheckDB(db ycsb.DB) error {
+	if c.doCAS && !ycsb.Supports(db, (*ycsb.CASDB)(nil)) {
+		return fmt.Errorf("%s needs a DB implementing the CASDB interface", prop.CASProportion)
+	}
+	return nil
 }
 
 // CleanupThread implements the Workload CleanupThread interface.
@@ -161,12 +262,7 @@ func (c *core) Close() error {
 }
 
 func (c *core) buildKeyName(keyNum int64) string {
-	if !c.orderedInserts {
-		keyNum = util.Hash64(keyNum)
-	}
-
-	prefix := c.p.GetString(prop.KeyPrefix, prop.KeyPrefixDefault)
-	return fmt.Sprintf("%s%0[3]*[2]d", prefix, keyNum, c.zeroPadding)
+	return c.keyFormat.key(keyNum)
 }
 
 func (c *core) buildSingleValue(state *coreState, key string) map[string][]byte {
@@ -179,7 +275,7 @@ func (c *core) buildSingleValue(state *coreState, key string) map[string][]byte
 	if c.dataIntegrity {
 		buf = c.buildDeterministicValue(state, key, fieldKey)
 	} else {
-		buf = c.buildRandomValue(state)
+		buf = c.buildRandomValue(state, fieldKey)
 	}
 
 	values[fieldKey] = buf
@@ -195,7 +291,7 @@ func (c *core) buildValues(state *coreState, key string) map[string][]byte {
 		if c.dataIntegrity {
 			buf = c.buildDeterministicValue(state, key, fieldKey)
 		} else {
-			buf = c.buildRandomValue(state)
+			buf = c.buildRandomValue(state, fieldKey)
 		}
 
 		values[fieldKey] = buf
@@ -218,44 +314,170 @@ func (c *core) putValues(values map[string][]byte) {
 	}
 }
 
-func (c *core) buildRandomValue(state *coreState) []byte {
+func (c *core) buildRandomValue(state *coreState, fieldKey string) []byte {
 	// TODO: use pool for the buffer
 	r := state.r
-	buf := c.getValueBuffer(int(c.fieldLengthGenerator.Next(r)))
-	util.RandBytes(r, buf)
+	f := c.field(fieldKey)
+	return c.fillValue(r, f, f.length.Next(r))
+}
+
+// field returns the generation of a field. Some databases return the field
+// names in another case.
+func (c *core) field(fieldKey string) *coreField {
+	if f, ok := c.fields[fieldKey]; ok {
+		return f
+	}
+	if f, ok := c.fields[strings.ToLower(fieldKey)]; ok {
+		return f
+	}
+	return c.defaultField
+}
+
+// fillValue returns a value of the field from r, the int64 and float values
+// in decimal text.
+func (c *core) fillValue(r rand.Source, f *coreField, size int64) []byte {
+	switch f.typ {
+	case util.FieldInt64:
+		return strconv.AppendInt(c.getValueBuffer(0), r.Int63(), 10)
+	case util.FieldFloat:
+		return strconv.AppendFloat(c.getValueBuffer(0), float64(r.Int63()>>10)/(1<<53), 'g', -1, 64)
+	}
+	buf := c.getValueBuffer(int(size))
+	f.value.Fill(r, buf)
 	return buf
 }
 
+// integrityHeaderLen is the length of the header of the values written with
+// dataintegrity: 'v', the version in 16 hex digits and the value length in 8
+// hex digits. Any later read can rebuild a value from its header, so the
+// values stay verifiable after updates.
+const integrityHeaderLen = 25
+
+// The JSON values keep the version and the length in their first member
+// instead, {"v":"<24 hex digits>", so they stay JSON objects.
+const (
+	jsonHeaderPrefix = `{"v":"`
+	jsonHeaderLen    = len(jsonHeaderPrefix) + integrityHeaderLen
+)
+
 func (c *core) buildDeterministicValue(state *coreState, key string, fieldKey string) []byte {
-	// TODO: use pool for the buffer
-	r := state.r
-	size := c.fieldLengthGenerator.Next(r)
-	buf := c.getValueBuffer(int(size + 21))
-	b := bytes.NewBuffer(buf[0:0])
-	b.WriteString(key)
-	b.WriteByte(':')
-	b.WriteString(strings.ToLower(fieldKey))
-	for int64(b.Len()) < size {
-		b.WriteByte(':')
-		n := util.BytesHash64(b.Bytes())
-		b.WriteString(strconv.FormatUint(uint64(n), 10))
-	}
-	b.Truncate(int(size))
-	return b.Bytes()
-}
-
-func (c *core) verifyRow(state *coreState, key string, values map[string][]byte) {
-	if len(values) == 0 {
-		// null data here, need panic?
+	f := c.field(fieldKey)
+	size := f.length.Next(state.r)
+	return c.buildVersionedValue(f, key, fieldKey, state.r.Int63(), size)
+}
+
+// buildVersionedValue returns the value of a field of key seeded by the key,
+// field and version. The int64 and float values have no room for a header,
+// so they only depend on the key and field.
+func (c *core) buildVersionedValue(f *coreField, key string, fieldKey string, version int64, size int64) []byte {
+	seed := util.SplitMix64(util.StringHash64(key + ":" + strings.ToLower(fieldKey)))
+	if f.typ == util.FieldInt64 || f.typ == util.FieldFloat {
+		return c.fillValue(&seed, f, size)
+	}
+
+	seed = util.SplitMix64(uint64(seed) ^ uint64(version))
+	if f.json {
+		// the header needs a closing brace at least.
+		if size <= int64(jsonHeaderLen) {
+			size = int64(jsonHeaderLen) + 1
+		}
+		buf := c.getValueBuffer(int(size))
+		copy(buf, jsonHeaderPrefix)
+		putStamp(buf[len(jsonHeaderPrefix):], version, size)
+		buf[jsonHeaderLen-1] = '"'
+		fillJSONMembers(f, &seed, buf[jsonHeaderLen:])
+		return buf
+	}
+
+	if size < integrityHeaderLen {
+		size = integrityHeaderLen
+	}
+	buf := c.getValueBuffer(int(size))
+	buf[0] = 'v'
+	putStamp(buf[1:], version, size)
+	f.value.Fill(&seed, buf[integrityHeaderLen:])
+	return buf
+}
+
+// fillJSONMembers fills the rest of a JSON value after its header with the
+// next members and the closing brace.
+func fillJSONMembers(f *coreField, r rand.Source, b []byte) {
+	if len(b) < 3 {
+		for i := range b {
+			b[i] = ' '
+		}
+		b[len(b)-1] = '}'
 		return
 	}
+	// the generated object follows the header member.
+	f.value.Fill(r, b)
+	b[0] = ','
+}
+
+// putStamp writes the version and the value length in 24 hex digits.
+func putStamp(b []byte, version int64, size int64) {
+	putHex(b[:16], uint64(version))
+	putHex(b[16:24], uint64(size))
+}
+
+// parseStamp parses the version and the value length written by putStamp.
+func parseStamp(b []byte) (version uint64, size uint64, ok bool) {
+	var err error
+	if version, err = strconv.ParseUint(string(b[:16]), 16, 64); err != nil {
+		return 0, 0, false
+	}
+	if size, err = strconv.ParseUint(string(b[16:24]), 16, 64); err != nil {
+		return 0, 0, false
+	}
+	return version, size, true
+}
+
+// putHex writes v in len(b) hex digits.
+func putHex(b []byte, v uint64) {
+	const digits = "0123456789abcdef"
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i] = digits[v&15]
+		v >>= 4
+	}
+}
+
+// verifyValue rebuilds a value of a field of key from its header and
+// returns whether it is the same.
+func (c *core) verifyValue(key string, fieldKey string, value []byte) bool {
+	f := c.field(fieldKey)
+	var version, size uint64
+	if f.typ != util.FieldInt64 && f.typ != util.FieldFloat {
+		var stamp []byte
+		if f.json {
+			if len(value) <= jsonHeaderLen || !bytes.HasPrefix(value, []byte(jsonHeaderPrefix)) {
+				return false
+			}
+			stamp = value[len(jsonHeaderPrefix):]
+		} else {
+			if len(value) < integrityHeaderLen || value[0] != 'v' {
+				return false
+			}
+			stamp = value[1:]
+		}
+		var ok bool
+		if version, size, ok = parseStamp(stamp); !ok || size != uint64(len(value)) {
+			return false
+		}
+	}
 
+	expected := c.buildVersionedValue(f, key, fieldKey, int64(version), int64(size))
+	defer c.valuePool.Put(expected)
+	return bytes.Equal(expected, value)
+}
+
+// verifyRow returns whether all the values read of key are the ones written.
+func (c *core) verifyRow(key string, values map[string][]byte) bool {
 	for fieldKey, value := range values {
-		expected := c.buildDeterministicValue(state, key, fieldKey)
-		if !bytes.Equal(expected, value) {
-			util.Fatalf("unexpected deterministic value, expect %q, but got %q", expected, value)
+		if !c.verifyValue(key, fieldKey, value) {
+			return false
 		}
 	}
+	return true
 }
 
 // DoInsert implements the Workload DoInsert interface.
@@ -358,6 +580,7 @@ func (c *core) DoBatchInsert(ctx context.Context, batchSize int, db ycsb.DB) err
 // DoTransaction implements the Workload DoTransaction interface.
 func (c *core) DoTransaction(ctx context.Context, db ycsb.DB) error {
 	state := ctx.Value(stateKey).(*coreState)
+	state.session = ycsb.GetUserSession(ctx)
 	r := state.r
 
 	operation := operationType(c.operationChooser.Next(r))
@@ -370,8 +593,42 @@ func (c *core) DoTransaction(ctx context.Context, db ycsb.DB) error {
 		return c.doTransactionInsert(ctx, db, state)
 	case scan:
 		return c.doTransactionScan(ctx, db, state)
-	default:
+	case remove:
+		return c.doTransactionDelete(ctx, db, state)
+	case readModifyWrite:
 		return c.doTransactionReadModifyWrite(ctx, db, state)
+	case compareAndSwap:
+		return c.doTransactionCAS(ctx, db, state)
+	default:
+		return c.doBatchOperation(ctx, db, state, operation)
+	}
+}
+
+// doBatchOperation runs a batch operation of the operation mix, with a batch
+// size of its own distribution.
+func (c *core) doBatchOperation(ctx context.Context, db ycsb.DB, state *coreState, operation operationType) error {
+	batchDB, ok := db.(ycsb.BatchDB)
+	if !ok {
+		return fmt.Errorf("the %T does't implement the batchDB interface", db)
+	}
+	batchSize := int(c.batchSizes[operation].Next(state.r))
+	if batchSize < 1 {
+		batchSize = 1
+	}
+
+	switch operation {
+	case batchRead:
+		return c.doBatchTransactionRead(ctx, batchSize, batchDB, state)
+	case batchUpdate:
+		return c.doBatchTransactionUpdate(ctx, batchSize, batchDB, state)
+	case batchInsert:
+		return c.doBatchTransactionInsert(ctx, batchSize, batchDB, state)
+	case batchScan:
+		return c.doBatchTransactionScan(ctx, batchSize, batchDB, state)
+	case batchRemove:
+		return c.doBatchTransactionDelete(ctx, batchSize, batchDB, state)
+	default:
+		return c.doBatchTransactionReadModifyWrite(ctx, batchSize, batchDB, state)
 	}
 }
 
@@ -382,6 +639,7 @@ func (c *core) DoBatchTransaction(ctx context.Context, batchSize int, db ycsb.DB
 		return fmt.Errorf("the %T does't implement the batchDB interface", db)
 	}
 	state := ctx.Value(stateKey).(*coreState)
+	state.session = ycsb.GetUserSession(ctx)
 	r := state.r
 
 	operation := operationType(c.operationChooser.Next(r))
@@ -393,22 +651,40 @@ func (c *core) DoBatchTransaction(ctx context.Context, batchSize int, db ycsb.DB
 	case update:
 		return c.doBatchTransactionUpdate(ctx, batchSize, batchDB, state)
 	case scan:
-		panic("The batch mode don't support the scan operation")
+		return c.doBatchTransactionScan(ctx, batchSize, batchDB, state)
+	case remove:
+		return c.doBatchTransactionDelete(ctx, batchSize, batchDB, state)
+	case readModifyWrite:
+		return c.doBatchTransactionReadModifyWrite(ctx, batchSize, batchDB, state)
+	case compareAndSwap:
+		return c.doTransactionCAS(ctx, db, state)
 	default:
-		return nil
+		return c.doBatchOperation(ctx, db, state, operation)
 	}
 }
 
 func (c *core) nextKeyNum(state *coreState) int64 {
 	r := state.r
+	// the records of a session follow the first one it chooses, wrapping
+	// around at the end of the loaded records.
+	if s := state.session; s != nil && c.sessionKeys > 0 && s.KeyBase >= 0 {
+		return c.insertStart + (s.KeyBase-c.insertStart+r.Int63n(c.sessionKeys))%c.insertCount
+	}
+	keyChooser := c.keyChooser
+	if state.keyChooser != nil {
+		keyChooser = state.keyChooser
+	}
 	keyNum := int64(0)
-	if _, ok := c.keyChooser.(*generator.Exponential); ok {
+	if _, ok := keyChooser.(*generator.Exponential); ok {
 		keyNum = -1
 		for keyNum < 0 {
-			keyNum = c.transactionInsertKeySequence.Last() - c.keyChooser.Next(r)
+			keyNum = c.transactionInsertKeySequence.Last() - keyChooser.Next(r)
 		}
 	} else {
-		keyNum = c.keyChooser.Next(r)
+		keyNum = keyChooser.Next(r)
+	}
+	if s := state.session; s != nil && c.sessionKeys > 0 && keyNum >= c.insertStart {
+		s.KeyBase = keyNum
 	}
 	return keyNum
 }
@@ -426,13 +702,23 @@ func (c *core) doTransactionRead(ctx context.Context, db ycsb.DB, state *coreSta
 		fields = state.fieldNames
 	}
 
+	deleted := c.isDeleted(keyNum)
+	if deleted {
+		ctx = ycsb.WithExpectedMiss(ctx)
+	}
+
+	start := time.Now()
 	values, err := db.Read(ctx, c.table, keyName, fields)
+	if deleted && isMiss(values, err) {
+		measurement.Measure("READ_EXPECTED_MISS", start, time.Now().Sub(start))
+		return nil
+	}
 	if err != nil {
 		return err
 	}
 
-	if c.dataIntegrity {
-		c.verifyRow(state, keyName, values)
+	if c.dataIntegrity && !c.verifyRow(keyName, values) {
+		measurement.Measure("INTEGRITY_ERROR", start, time.Now().Sub(start))
 	}
 
 	return nil
@@ -464,7 +750,16 @@ func (c *core) doTransactionReadModifyWrite(ctx context.Context, db ycsb.DB, sta
 	}
 	defer c.putValues(values)
 
+	deleted := c.isDeleted(keyNum)
+	if deleted {
+		ctx = ycsb.WithExpectedMiss(ctx)
+	}
+
 	readValues, err := db.Read(ctx, c.table, keyName, fields)
+	if deleted && isMiss(readValues, err) {
+		measurement.Measure("READ_MODIFY_WRITE_EXPECTED_MISS", start, time.Now().Sub(start))
+		return nil
+	}
 	if err != nil {
 		return err
 	}
@@ -473,10 +768,68 @@ func (c *core) doTransactionReadModifyWrite(ctx context.Context, db ycsb.DB, sta
 		return err
 	}
 
-	if c.dataIntegrity {
-		c.verifyRow(state, keyName, readValues)
+	if c.dataIntegrity && !c.verifyRow(keyName, readValues) {
+		measurement.Measure("INTEGRITY_ERROR", start, time.Now().Sub(start))
+	}
+
+	return nil
+}
+
+// doTransactionCAS reads a record with its version and then replaces it if
+// the version has not changed. CAS measures every attempt and CAS_FAILED the
+// ones losing to a concurrent write, so the failure ratio is the contention.
+func (c *core) doTransactionCAS(ctx context.Context, db ycsb.DB, state *coreState) error {
+	casDB, ok := db.(ycsb.CASDB)
+	if !ok {
+		return fmt.Errorf("the %T doesn't implement the CASDB interface", db)
+	}
+
+	start := time.Now()
+	keyNum := c.nextKeyNum(state)
+	keyName := c.buildKeyName(keyNum)
+
+	deleted := c.isDeleted(keyNum)
+	if deleted {
+		ctx = ycsb.WithExpectedMiss(ctx)
+	}
+
+	readValues, version, err := casDB.ReadVersion(ctx, c.table, keyName, nil)
+	if deleted && isMiss(readValues, err) {
+		measurement.Measure("CAS_EXPECTED_MISS", start, time.Now().Sub(start))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if c.dataIntegrity && !c.verifyRow(keyName, readValues) {
+		measurement.Measure("INTEGRITY_ERROR", start, time.Now().Sub(start))
+	}
+
+	var values map[string][]byte
+	if c.writeAllFields {
+		values = c.buildValues(state, keyName)
+	} else {
+		values = c.buildSingleValue(state, keyName)
 	}
+	defer c.putValues(values)
 
+	// The swap replaces the whole record, so it keeps the fields not written.
+	record := make(map[string][]byte, len(readValues)+len(values))
+	for field, value := range readValues {
+		record[field] = value
+	}
+	for field, value := range values {
+		record[field] = value
+	}
+
+	applied, err := casDB.CompareAndSwap(ctx, c.table, keyName, version, record)
+	if err != nil {
+		return err
+	}
+	if !applied {
+		measurement.Measure("CAS_FAILED", start, time.Now().Sub(start))
+	}
+	measurement.Measure("CAS", start, time.Now().Sub(start))
 	return nil
 }
 
@@ -488,7 +841,11 @@ func (c *core) doTransactionInsert(ctx context.Context, db ycsb.DB, state *coreS
 	values := c.buildValues(state, dbKey)
 	defer c.putValues(values)
 
-	return db.Insert(ctx, c.table, dbKey, values)
+	if err := db.Insert(ctx, c.table, dbKey, values); err != nil {
+		return err
+	}
+	c.deletedKeys.Remove(int(keyNum))
+	return nil
 }
 
 func (c *core) doTransactionScan(ctx context.Context, db ycsb.DB, state *coreState) error {
@@ -524,7 +881,96 @@ func (c *core) doTransactionUpdate(ctx context.Context, db ycsb.DB, state *coreS
 
 	defer c.putValues(values)
 
-	return db.Update(ctx, c.table, keyName, values)
+	if !c.isDeleted(keyNum) {
+		return db.Update(ctx, c.table, keyName, values)
+	}
+
+	start := time.Now()
+	err := db.Update(ycsb.WithExpectedMiss(ctx), c.table, keyName, values)
+	if errors.Is(err, ycsb.ErrNotFound) {
+		measurement.Measure("UPDATE_EXPECTED_MISS", start, time.Now().Sub(start))
+		return nil
+	}
+	return err
+}
+
+// nextDeleteKeyNum returns the key to delete, or false if all the inserted
+// keys have been deleted already.
+func (c *core) nextDeleteKeyNum(state *coreState) (int64, bool) {
+	if !c.deleteOldest {
+		return c.nextKeyNum(state), true
+	}
+
+	for {
+		keyNum := atomic.LoadInt64(&c.oldestKey)
+		if keyNum > c.transactionInsertKeySequence.Last() {
+			return 0, false
+		}
+		if atomic.CompareAndSwapInt64(&c.oldestKey, keyNum, keyNum+1) {
+			return keyNum, true
+		}
+	}
+}
+
+func (c *core) isDeleted(keyNum int64) bool {
+	// deletedBelow moves past a key before it is removed from deletedKeys.
+	if c.deletedKeys.Has(int(keyNum)) {
+		return true
+	}
+	return c.deleteOldest && keyNum >= c.insertStart && keyNum < atomic.LoadInt64(&c.deletedBelow) &&
+		!c.undeletedKeys.Has(int(keyNum))
+}
+
+// markDeleted records the end of the delete of keyNum, ok if it succeeded.
+// The keys deleted by the chooser stay marked until they are inserted again,
+// and the oldest keys are only kept apart until the keys before them are
+// deleted, as they are never inserted again.
+func (c *core) markDeleted(keyNum int64, ok bool) {
+	if !c.deleteOldest {
+		if ok {
+			c.deletedKeys.Set(int(keyNum), time.Now().UnixNano())
+		}
+		return
+	}
+
+	c.deleteLock.Lock()
+	defer c.deleteLock.Unlock()
+	if ok {
+		c.deletedKeys.Set(int(keyNum), time.Now().UnixNano())
+	} else {
+		c.undeletedKeys.Set(int(keyNum), time.Now().UnixNano())
+	}
+	for {
+		next := c.deletedBelow
+		deleted := c.deletedKeys.Has(int(next))
+		if !deleted && !c.undeletedKeys.Has(int(next)) {
+			return
+		}
+		atomic.StoreInt64(&c.deletedBelow, next+1)
+		if deleted {
+			c.deletedKeys.Remove(int(next))
+		}
+	}
+}
+
+// isMiss reports whether a read found no record, the other errors are not
+// expected misses.
+func isMiss(values map[string][]byte, err error) bool {
+	if err != nil {
+		return errors.Is(err, ycsb.ErrNotFound)
+	}
+	return len(values) == 0
+}
+
+func (c *core) doTransactionDelete(ctx context.Context, db ycsb.DB, state *coreState) error {
+	keyNum, ok := c.nextDeleteKeyNum(state)
+	if !ok {
+		return nil
+	}
+
+	err := db.Delete(ctx, c.table, c.buildKeyName(keyNum))
+	c.markDeleted(keyNum, err == nil)
+	return err
 }
 
 func (c *core) doBatchTransactionRead(ctx context.Context, batchSize int, db ycsb.BatchDB, state *coreState) error {
@@ -543,12 +989,19 @@ func (c *core) doBatchTransactionRead(ctx context.Context, batchSize int, db ycs
 		keys[i] = c.buildKeyName(c.nextKeyNum(state))
 	}
 
-	_, err := db.BatchRead(ctx, c.table, keys, fields)
+	start := time.Now()
+	rows, err := db.BatchRead(ctx, c.table, keys, fields)
 	if err != nil {
 		return err
 	}
 
-	// TODO should we verify the result?
+	if c.dataIntegrity {
+		for i, row := range rows {
+			if i < len(keys) && !c.verifyRow(keys[i], row) {
+				measurement.Measure("INTEGRITY_ERROR", start, time.Now().Sub(start))
+			}
+		}
+	}
 	return nil
 }
 
@@ -600,6 +1053,103 @@ func (c *core) doBatchTransactionUpdate(ctx context.Context, batchSize int, db y
 	return db.BatchUpdate(ctx, c.table, keys, values)
 }
 
+func (c *core) doBatchTransactionScan(ctx context.Context, batchSize int, db ycsb.BatchDB, state *coreState) error {
+	r := state.r
+	var fields []string
+
+	if !c.readAllFields {
+		fieldName := state.fieldNames[c.fieldChooser.Next(r)]
+		fields = append(fields, fieldName)
+	} else {
+		fields = state.fieldNames
+	}
+
+	startKeys := make([]string, batchSize)
+	counts := make([]int, batchSize)
+	for i := 0; i < batchSize; i++ {
+		startKeys[i] = c.buildKeyName(c.nextKeyNum(state))
+		counts[i] = int(c.scanLength.Next(r))
+	}
+
+	_, err := db.BatchScan(ctx, c.table, startKeys, counts, fields)
+	return err
+}
+
+// doBatchTransactionReadModifyWrite reads a batch of records and then
+// updates them in another batch.
+func (c *core) doBatchTransactionReadModifyWrite(ctx context.Context, batchSize int, db ycsb.BatchDB, state *coreState) error {
+	start := time.Now()
+	defer func() {
+		measurement.Measure("BATCH_READ_MODIFY_WRITE", start, time.Now().Sub(start))
+	}()
+
+	r := state.r
+	var fields []string
+	if !c.readAllFields {
+		fieldName := state.fieldNames[c.fieldChooser.Next(r)]
+		fields = append(fields, fieldName)
+	} else {
+		fields = state.fieldNames
+	}
+
+	keys := make([]string, batchSize)
+	values := make([]map[string][]byte, batchSize)
+	for i := 0; i < batchSize; i++ {
+		keyName := c.buildKeyName(c.nextKeyNum(state))
+		keys[i] = keyName
+		if c.writeAllFields {
+			values[i] = c.buildValues(state, keyName)
+		} else {
+			values[i] = c.buildSingleValue(state, keyName)
+		}
+	}
+	defer func() {
+		for _, value := range values {
+			c.putValues(value)
+		}
+	}()
+
+	rows, err := db.BatchRead(ctx, c.table, keys, fields)
+	if err != nil {
+		return err
+	}
+
+	if err := db.BatchUpdate(ctx, c.table, keys, values); err != nil {
+		return err
+	}
+
+	if c.dataIntegrity {
+		for i, row := range rows {
+			if i < len(keys) && !c.verifyRow(keys[i], row) {
+				measurement.Measure("INTEGRITY_ERROR", start, time.Now().Sub(start))
+			}
+		}
+	}
+	return nil
+}
+
+func (c *core) doBatchTransactionDelete(ctx context.Context, batchSize int, db ycsb.BatchDB, state *coreState) error {
+	keyNums := make([]int64, 0, batchSize)
+	keys := make([]string, 0, batchSize)
+	for i := 0; i < batchSize; i++ {
+		keyNum, ok := c.nextDeleteKeyNum(state)
+		if !ok {
+			break
+		}
+		keyNums = append(keyNums, keyNum)
+		keys = append(keys, c.buildKeyName(keyNum))
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	err := db.BatchDelete(ctx, c.table, keys)
+	for _, keyNum := range keyNums {
+		c.markDeleted(keyNum, err == nil)
+	}
+	return err
+}
+
 // CoreCreator creates the Core workload.
 type coreCreator struct {
 }
@@ -609,12 +1159,58 @@ func (coreCreator) Create(p *properties.Properties) (ycsb.Workload, error) {
 	c := new(core)
 	c.p = p
 	c.table = p.GetString(prop.TableName, prop.TableNameDefault)
-	c.fieldCount = p.GetInt64(prop.FieldCount, prop.FieldCountDefault)
-	c.fieldNames = make([]string, c.fieldCount)
-	for i := int64(0); i < c.fieldCount; i++ {
-		c.fieldNames[i] = fmt.Sprintf("field%d", i)
+	schema, err := util.NewSchema(p)
+	if err != nil {
+		return nil, err
+	}
+	c.fieldNames = schema.FieldNames()
+	c.fieldCount = int64(len(c.fieldNames))
+	// the session check prefixes every value with its header, which the
+	// typed fields can't hold.
+	if p.GetBool(prop.SessionCheck, prop.SessionCheckDefault) && p.GetBool(prop.DoTransactions, true) {
+		for _, sf := range schema.Fields {
+			if sf.Type == util.FieldInt64 || sf.Type == util.FieldFloat || sf.Type == util.FieldJSON {
+				return nil, fmt.Errorf("%s can't check the %s field %s, only string and bytes fields", prop.SessionCheck, sf.Type, sf.Name)
+			}
+		}
+	}
+
+	// A field takes the global length and value generation, unless the
+	// schema or the properties suffixed with its name override them.
+	fieldLengthDistribution := p.GetString(prop.FieldLengthDistribution, prop.FieldLengthDistributionDefault)
+	fieldLength := p.GetInt64(prop.FieldLength, prop.FieldLengthDefault)
+	valueMode := p.GetString(prop.ValueMode, prop.ValueModeDefault)
+	compressionRatio := p.GetFloat64(prop.CompressionRatio, prop.CompressionRatioDefault)
+	c.fieldLengthGenerator = getFieldLengthGenerator(p, fieldLengthDistribution, fieldLength)
+	c.defaultField = &coreField{typ: util.FieldBytes, length: c.fieldLengthGenerator, json: valueMode == util.ValueModeJSON}
+	if c.defaultField.value, err = util.NewValueGenerator(valueMode, compressionRatio); err != nil {
+		return nil, err
+	}
+	c.dataIntegrity = p.GetBool(prop.DataIntegrity, prop.DataIntegrityDefault)
+	c.fields = make(map[string]*coreField, c.fieldCount)
+	for _, sf := range schema.Fields {
+		f := &coreField{typ: sf.Type, length: c.fieldLengthGenerator}
+		distribution := p.GetString(prop.FieldLengthDistribution+"."+sf.Name, fieldLengthDistribution)
+		length := sf.Length
+		if length == 0 {
+			length = p.GetInt64(prop.FieldLength+"."+sf.Name, fieldLength)
+		}
+		if distribution != fieldLengthDistribution || length != fieldLength {
+			f.length = getFieldLengthGenerator(p, distribution, length)
+		}
+
+		mode := valueMode
+		if sf.Type == util.FieldJSON {
+			mode = util.ValueModeJSON
+		}
+		mode = p.GetString(prop.ValueMode+"."+sf.Name, mode)
+		f.json = mode == util.ValueModeJSON
+		f.value, err = util.NewValueGenerator(mode, p.GetFloat64(prop.CompressionRatio+"."+sf.Name, compressionRatio))
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %v", sf.Name, err)
+		}
+		c.fields[sf.Name] = f
 	}
-	c.fieldLengthGenerator = getFieldLengthGenerator(p)
 	c.recordCount = p.GetInt64(prop.RecordCount, prop.RecordCountDefault)
 	if c.recordCount == 0 {
 		c.recordCount = int64(math.MaxInt32)
@@ -630,23 +1226,51 @@ func (coreCreator) Create(p *properties.Properties) (ycsb.Workload, error) {
 		util.Fatalf("record count %d must be bigger than insert start %d + count %d",
 			c.recordCount, insertStart, insertCount)
 	}
-	c.zeroPadding = p.GetInt64(prop.ZeroPadding, prop.ZeroPaddingDefault)
 	c.readAllFields = p.GetBool(prop.ReadAllFields, prop.ReadALlFieldsDefault)
 	c.writeAllFields = p.GetBool(prop.WriteAllFields, prop.WriteAllFieldsDefault)
-	c.dataIntegrity = p.GetBool(prop.DataIntegrity, prop.DataIntegrityDefault)
-	fieldLengthDistribution := p.GetString(prop.FieldLengthDistribution, prop.FieldLengthDistributionDefault)
-	if c.dataIntegrity && fieldLengthDistribution != "constant" {
-		util.Fatal("must have constant field size to check data integrity")
+
+	keyFormat, err := newKeyFormat(p)
+	if err != nil {
+		return nil, err
 	}
+	c.keyFormat = keyFormat
 
-	if p.GetString(prop.InsertOrder, prop.InsertOrderDefault) == "hashed" {
-		c.orderedInserts = false
-	} else {
-		c.orderedInserts = true
+	switch deleteOrder := p.GetString(prop.DeleteOrder, prop.DeleteOrderDefault); deleteOrder {
+	case "oldest":
+		c.deleteOldest = true
+	case "chooser":
+		c.deleteOldest = false
+	default:
+		util.Fatalf("unknown delete order %s", deleteOrder)
 	}
+	c.oldestKey = insertStart
+	c.insertStart, c.insertCount = insertStart, insertCount
+	c.sessionKeys = p.GetInt64(prop.UserSessionKeys, prop.UserSessionKeysDefault)
+	if c.sessionKeys > insertCount {
+		c.sessionKeys = insertCount
+	}
+	c.deletedKeys = util.New(32)
+	c.deletedBelow = insertStart
+	c.undeletedKeys = util.New(32)
 
 	c.keySequence = generator.NewCounter(insertStart)
 	c.operationChooser = createOperationGenerator(p)
+	c.doCAS = p.GetBool(prop.DoTransactions, true) && p.GetFloat64(prop.CASProportion, prop.CASProportionDefault) > 0
+	if c.doCAS {
+		measurement.ReportSuccessRate("CAS", "CAS_FAILED")
+	}
+	c.batchSizes = make(map[operationType]ycsb.Generator)
+	for _, b := range batchOperations {
+		if p.GetFloat64(b.proportion, 0) <= 0 {
+			continue
+		}
+		spec := p.GetString(b.size, p.GetString(prop.BatchOpSize, prop.BatchOpSizeDefault))
+		gen, err := generator.ParseSpec(spec, 1, maxBatchOpSize)
+		if err != nil {
+			return nil, fmt.Errorf("bad %s %s: %v", b.size, spec, err)
+		}
+		c.batchSizes[b.op] = gen
+	}
 	var keyrangeLowerBound int64 = insertStart
 	var keyrangeUpperBound int64 = insertStart + insertCount - 1
 
@@ -668,12 +1292,40 @@ func (coreCreator) Create(p *properties.Properties) (ycsb.Workload, error) {
 		hotsetFraction := p.GetFloat64(prop.HotspotDataFraction, prop.HotspotDataFractionDefault)
 		hotopnFraction := p.GetFloat64(prop.HotspotOpnFraction, prop.HotspotOpnFractionDefault)
 		c.keyChooser = generator.NewHotspot(keyrangeLowerBound, keyrangeUpperBound, hotsetFraction, hotopnFraction)
+	case "movinghotspot":
+		hotsetFraction := p.GetFloat64(prop.HotspotDataFraction, prop.HotspotDataFractionDefault)
+		hotopnFraction := p.GetFloat64(prop.HotspotOpnFraction, prop.HotspotOpnFractionDefault)
+		speed := p.GetFloat64(prop.HotspotSpeed, prop.HotspotSpeedDefault)
+		c.keyChooser = generator.NewMovingHotspot(keyrangeLowerBound, keyrangeUpperBound, hotsetFraction, hotopnFraction, speed)
+	case "jumpinghotspot":
+		hotsetFraction := p.GetFloat64(prop.HotspotDataFraction, prop.HotspotDataFractionDefault)
+		hotopnFraction := p.GetFloat64(prop.HotspotOpnFraction, prop.HotspotOpnFractionDefault)
+		period := p.GetParsedDuration(prop.HotspotPeriod, 10*time.Second)
+		c.keyChooser = generator.NewJumpingHotspot(keyrangeLowerBound, keyrangeUpperBound, hotsetFraction, hotopnFraction, period)
+	case "conflict":
+		sharedKeys := p.GetInt64(prop.ConflictSharedKeys, prop.ConflictSharedKeysDefault)
+		conflicts := p.GetFloat64(prop.Conflicts, prop.ConflictsDefault)
+		c.threadKeyChooser = func(threadID int, threadCount int) ycsb.Generator {
+			return generator.NewConflict(keyrangeLowerBound, keyrangeUpperBound, sharedKeys, conflicts, threadID, threadCount)
+		}
+	case "normal":
+		mu := p.GetFloat64(prop.NormalMu, prop.NormalMuDefault)
+		sigma := p.GetFloat64(prop.NormalSigma, prop.NormalSigmaDefault)
+		var speed time.Duration
+		if p.GetBool(prop.NormalMove, prop.NormalMoveDefault) {
+			speed = time.Duration(p.GetInt64(prop.NormalSpeed, prop.NormalSpeedDefault)) * time.Millisecond
+		}
+		c.keyChooser = generator.NewNormal(keyrangeLowerBound, keyrangeUpperBound, mu, sigma, speed)
 	case "exponential":
 		percentile := p.GetFloat64(prop.ExponentialPercentile, prop.ExponentialPercentileDefault)
 		frac := p.GetFloat64(prop.ExponentialFrac, prop.ExponentialFracDefault)
 		c.keyChooser = generator.NewExponential(percentile, float64(c.recordCount)*frac)
 	default:
-		util.Fatalf("unknown request distribution %s", requestDistrib)
+		gen, err := generator.ParseSpec(requestDistrib, keyrangeLowerBound, keyrangeUpperBound)
+		if err != nil {
+			util.Fatalf("unknown request distribution %s: %v", requestDistrib, err)
+		}
+		c.keyChooser = gen
 	}
 	fmt.Println(fmt.Sprintf("Using request distribution '%s' a keyrange of [%d %d]", requestDistrib, keyrangeLowerBound, keyrangeUpperBound))
 
@@ -684,13 +1336,16 @@ func (coreCreator) Create(p *properties.Properties) (ycsb.Workload, error) {
 	case "zipfian":
 		c.scanLength = generator.NewZipfianWithRange(1, maxScanLength, generator.ZipfianConstant)
 	default:
-		util.Fatalf("distribution %s not allowed for scan length", scanLengthDistrib)
+		gen, err := generator.ParseSpec(scanLengthDistrib, 1, maxScanLength)
+		if err != nil {
+			util.Fatalf("distribution %s not allowed for scan length: %v", scanLengthDistrib, err)
+		}
+		c.scanLength = generator.NewClamp(gen, 1, math.MaxInt32)
 	}
 
 	c.insertionRetryLimit = p.GetInt64(prop.InsertionRetryLimit, prop.InsertionRetryLimitDefault)
 	c.insertionRetryInterval = p.GetInt64(prop.InsertionRetryInterval, prop.InsertionRetryIntervalDefault)
 
-	fieldLength := p.GetInt64(prop.FieldLength, prop.FieldLengthDefault)
 	c.valuePool = sync.Pool{
 		New: func() interface{} {
 			return make([]byte, fieldLength)
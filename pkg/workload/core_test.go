@@ -0,0 +1,62 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/magiconair/properties"
+)
+
+// failingDeleteDB fails the deletes of a key.
+type failingDeleteDB struct {
+	*memDB
+	key string
+}
+
+func (db failingDeleteDB) Delete(ctx context.Context, table string, key string) error {
+	if key == db.key {
+		return errors.New("delete failed")
+	}
+	return db.memDB.Delete(ctx, table, key)
+}
+
+func TestDeleteOldest(t *testing.T) {
+	p := properties.MustLoadString("recordcount=10\ndeleteorder=oldest")
+	w, err := coreCreator{}.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := w.(*core)
+	db := failingDeleteDB{memDB: newMemDB(), key: c.buildKeyName(4)}
+
+	ctx := c.InitThread(context.Background(), 0, 1)
+	state := ctx.Value(stateKey).(*coreState)
+	for i := 0; i < 10; i++ {
+		if err := c.doTransactionDelete(ctx, db, state); (err != nil) != (i == 4) {
+			t.Fatalf("delete %d: %v", i, err)
+		}
+	}
+	for keyNum := int64(0); keyNum < 10; keyNum++ {
+		if c.isDeleted(keyNum) != (keyNum != 4) {
+			t.Fatalf("key %d is deleted: %v", keyNum, c.isDeleted(keyNum))
+		}
+	}
+	// the oldest keys deleted in order are not kept one by one.
+	if n := c.deletedKeys.Count(); n != 0 {
+		t.Fatalf("expect no key kept apart, got %d", n)
+	}
+}
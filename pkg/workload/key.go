@@ -0,0 +1,343 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/generator"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+const (
+	// keyFormatDefault is keyprefix and the zero padded decimal record
+	// number, hashed unless insertorder is ordered.
+	keyFormatDefault = iota
+	// keyFormatTemplate fills the placeholders of a template.
+	keyFormatTemplate
+	// keyFormatBinary is keyprefix and the 8 bytes big-endian record number,
+	// scrambled unless insertorder is ordered.
+	keyFormatBinary
+)
+
+// keyPart is a literal or a placeholder of a key template.
+type keyPart struct {
+	literal string
+	name    string
+	verb    string
+}
+
+// keyFormat maps the record numbers to keys and back.
+type keyFormat struct {
+	kind        int
+	prefix      string
+	zeroPadding int64
+	hashed      bool
+
+	parts   []keyPart
+	re      *regexp.Regexp
+	shards  int64
+	client  string
+	tenants int64
+
+	// length generates the length of a key from its record number, the
+	// shorter keys are padded with the letters g to z, which no decimal or
+	// hex placeholder has. nil keeps the natural length.
+	length ycsb.Generator
+}
+
+const mask63 = 1<<63 - 1
+
+const (
+	scrambleMul1 = 0x7fb5d329728ea185
+	scrambleMul2 = 0x81dadef4bc2dd44d
+)
+
+// scramble is a permutation of the non-negative int64s, so unlike
+// util.Hash64 a scrambled record number can be mapped back.
+func scramble(n int64) int64 {
+	x := uint64(n) & mask63
+	x ^= x >> 31
+	x = (x * scrambleMul1) & mask63
+	x ^= x >> 27
+	x = (x * scrambleMul2) & mask63
+	x ^= x >> 33
+	return int64(x)
+}
+
+func unxorshift(x uint64, shift uint) uint64 {
+	y := x
+	for i := uint(0); i < 63; i += shift {
+		y = x ^ (y >> shift)
+	}
+	return y
+}
+
+// inverseOdd returns the multiplicative inverse of an odd number modulo 2^64.
+func inverseOdd(a uint64) uint64 {
+	x := a
+	for i := 0; i < 6; i++ {
+		x *= 2 - a*x
+	}
+	return x
+}
+
+// unscramble is the inverse of scramble.
+func unscramble(n int64) int64 {
+	x := uint64(n) & mask63
+	x = unxorshift(x, 33)
+	x = (x * inverseOdd(scrambleMul2)) & mask63
+	x = unxorshift(x, 27)
+	x = (x * inverseOdd(scrambleMul1)) & mask63
+	x = unxorshift(x, 31)
+	return int64(x)
+}
+
+var keyPlaceholders = map[string]string{
+	"num":    "d",
+	"hash":   "d",
+	"shard":  "d",
+	"tenant": "d",
+	"client": "s",
+}
+
+// parseKeyTemplate parses a template like "tenant{tenant}:user{hash:016x}".
+func parseKeyTemplate(template string) ([]keyPart, error) {
+	var parts []keyPart
+	for len(template) > 0 {
+		i := strings.IndexByte(template, '{')
+		if i < 0 {
+			parts = append(parts, keyPart{literal: template})
+			break
+		}
+		if i > 0 {
+			parts = append(parts, keyPart{literal: template[:i]})
+		}
+		j := strings.IndexByte(template[i:], '}')
+		if j < 0 {
+			return nil, fmt.Errorf("unclosed { in key template")
+		}
+		name, verb := template[i+1:i+j], ""
+		if k := strings.IndexByte(name, ':'); k >= 0 {
+			name, verb = name[:k], name[k+1:]
+		}
+		def, ok := keyPlaceholders[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown placeholder {%s} in key template", name)
+		}
+		if verb == "" {
+			verb = def
+		}
+		if last := verb[len(verb)-1]; strings.IndexByte("dxXs", last) < 0 || (last == 's') != (def == "s") {
+			return nil, fmt.Errorf("bad format %s of placeholder {%s} in key template", verb, name)
+		}
+		parts = append(parts, keyPart{name: name, verb: verb})
+		template = template[i+j+1:]
+	}
+	return parts, nil
+}
+
+// keyPattern returns the regular expression of a placeholder.
+func keyPattern(p keyPart) string {
+	var chars string
+	switch p.verb[len(p.verb)-1] {
+	case 'd':
+		chars = "[0-9]"
+	case 'x':
+		chars = "[0-9a-f]"
+	case 'X':
+		chars = "[0-9A-F]"
+	default:
+		return "(.*?)"
+	}
+	// a zero padded width is fixed unless the number is longer
+	if width, err := strconv.Atoi(strings.TrimPrefix(p.verb[:len(p.verb)-1], "0")); err == nil && width > 0 && p.verb[0] == '0' {
+		return fmt.Sprintf("(%s{%d,})", chars, width)
+	}
+	return fmt.Sprintf("(%s+)", chars)
+}
+
+func newKeyFormat(p *properties.Properties) (*keyFormat, error) {
+	f := &keyFormat{
+		prefix:      p.GetString(prop.KeyPrefix, prop.KeyPrefixDefault),
+		zeroPadding: p.GetInt64(prop.ZeroPadding, prop.ZeroPaddingDefault),
+		hashed:      p.GetString(prop.InsertOrder, prop.InsertOrderDefault) == "hashed",
+		shards:      p.GetInt64(prop.KeyShards, prop.KeyShardsDefault),
+		client:      p.GetString(prop.KeyClient, prop.KeyClientDefault),
+		tenants:     p.GetInt64(prop.KeyTenants, prop.KeyTenantsDefault),
+	}
+	if f.shards <= 0 || f.tenants <= 0 {
+		return nil, fmt.Errorf("%s and %s must be positive", prop.KeyShards, prop.KeyTenants)
+	}
+
+	switch format := p.GetString(prop.KeyFormat, prop.KeyFormatDefault); {
+	case format == "":
+		f.kind = keyFormatDefault
+	case format == "binary":
+		f.kind = keyFormatBinary
+	default:
+		f.kind = keyFormatTemplate
+		parts, err := parseKeyTemplate(format)
+		if err != nil {
+			return nil, err
+		}
+		reversible := false
+		var pattern strings.Builder
+		pattern.WriteString("^")
+		for _, part := range parts {
+			if part.name == "" {
+				pattern.WriteString(regexp.QuoteMeta(part.literal))
+				continue
+			}
+			reversible = reversible || part.name == "num" || part.name == "hash"
+			pattern.WriteString(keyPattern(part))
+		}
+		if !reversible {
+			return nil, fmt.Errorf("key template %s must have {num} or {hash}", format)
+		}
+		f.parts = parts
+		f.re = regexp.MustCompile(pattern.String())
+	}
+
+	if keyLength := p.GetInt64(prop.KeyLength, prop.KeyLengthDefault); keyLength > 0 {
+		if f.kind != keyFormatTemplate {
+			return nil, fmt.Errorf("%s needs a key template in %s", prop.KeyLength, prop.KeyFormat)
+		}
+		switch distribution := p.GetString(prop.KeyLengthDistribution, prop.KeyLengthDistributionDefault); distribution {
+		case "constant":
+			f.length = generator.NewConstant(keyLength)
+		default:
+			gen, err := generator.ParseSpec(distribution, 1, keyLength)
+			if err != nil {
+				return nil, fmt.Errorf("bad key length distribution %s: %v", distribution, err)
+			}
+			f.length = gen
+		}
+	}
+	return f, nil
+}
+
+func (f *keyFormat) placeholder(part keyPart, keyNum int64) interface{} {
+	switch part.name {
+	case "num":
+		return keyNum
+	case "hash":
+		return scramble(keyNum)
+	case "shard":
+		return keyNum % f.shards
+	case "tenant":
+		return keyNum % f.tenants
+	default:
+		return f.client
+	}
+}
+
+// key returns the key of a record.
+func (f *keyFormat) key(keyNum int64) string {
+	switch f.kind {
+	case keyFormatDefault:
+		if f.hashed {
+			keyNum = util.Hash64(keyNum)
+		}
+		return fmt.Sprintf("%s%0[3]*[2]d", f.prefix, keyNum, f.zeroPadding)
+	case keyFormatBinary:
+		if f.hashed {
+			keyNum = scramble(keyNum)
+		}
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], uint64(keyNum))
+		return f.prefix + string(b[:])
+	}
+
+	var b strings.Builder
+	for _, part := range f.parts {
+		if part.name == "" {
+			b.WriteString(part.literal)
+			continue
+		}
+		fmt.Fprintf(&b, "%"+part.verb, f.placeholder(part, keyNum))
+	}
+	if f.length != nil {
+		// The length and padding only depend on the record number, so
+		// every thread builds the same key.
+		seed := util.SplitMix64(keyNum)
+		r := rand.New(&seed)
+		if pad := int(f.length.Next(r)) - b.Len(); pad > 0 {
+			for i := 0; i < pad; i++ {
+				b.WriteByte(byte('g' + r.Intn('z'-'g'+1)))
+			}
+		}
+	}
+	return b.String()
+}
+
+// keyNum returns the record number of a key.
+func (f *keyFormat) keyNum(key string) (int64, error) {
+	switch f.kind {
+	case keyFormatDefault:
+		if f.hashed {
+			return 0, fmt.Errorf("hashed keys of the default format can't be mapped back, use %s", prop.KeyFormat)
+		}
+		if !strings.HasPrefix(key, f.prefix) {
+			return 0, fmt.Errorf("key %q has no prefix %s", key, f.prefix)
+		}
+		return strconv.ParseInt(key[len(f.prefix):], 10, 64)
+	case keyFormatBinary:
+		if len(key) != len(f.prefix)+8 || !strings.HasPrefix(key, f.prefix) {
+			return 0, fmt.Errorf("key %q is not prefix %s and 8 bytes", key, f.prefix)
+		}
+		keyNum := int64(binary.BigEndian.Uint64([]byte(key[len(f.prefix):])))
+		if f.hashed {
+			keyNum = unscramble(keyNum)
+		}
+		return keyNum, nil
+	}
+
+	m := f.re.FindStringSubmatch(key)
+	if m == nil {
+		return 0, fmt.Errorf("key %q doesn't match the key template", key)
+	}
+	i := 1
+	for _, part := range f.parts {
+		if part.name == "" {
+			continue
+		}
+		v := m[i]
+		i++
+		if part.name != "num" && part.name != "hash" {
+			continue
+		}
+		base := 10
+		if c := part.verb[len(part.verb)-1]; c == 'x' || c == 'X' {
+			base = 16
+		}
+		n, err := strconv.ParseInt(v, base, 64)
+		if err != nil {
+			return 0, fmt.Errorf("bad {%s} in key %q: %v", part.name, key, err)
+		}
+		if part.name == "hash" {
+			n = unscramble(n)
+		}
+		return n, nil
+	}
+	return 0, fmt.Errorf("key %q has no record number", key)
+}
@@ -0,0 +1,63 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"math"
+	"testing"
+
+	"github.com/magiconair/properties"
+)
+
+func TestKeyFormat(t *testing.T) {
+	tests := []string{
+		"insertorder=ordered",
+		"keyformat=binary",
+		"keyformat=binary\ninsertorder=ordered",
+		"keyformat=user{num}",
+		"keyformat=tenant{tenant}:user{hash:016x}\nkeytenants=4",
+		"keyformat={client}/{shard}/{hash:X}\nkeyclient=c-1\nkeyshards=8",
+		"keyformat=k{num:08d}\nkeylength=40\nkeylengthdistribution=uniform",
+		"keyformat=k{hash:x}\nkeylength=24",
+	}
+	for _, test := range tests {
+		f, err := newKeyFormat(properties.MustLoadString(test))
+		if err != nil {
+			t.Fatalf("%q: %v", test, err)
+		}
+		for _, n := range []int64{0, 1, 7, 12345, math.MaxInt64} {
+			key := f.key(n)
+			if f.key(n) != key {
+				t.Fatalf("%q: key of %d is not stable", test, n)
+			}
+			m, err := f.keyNum(key)
+			if err != nil || m != n {
+				t.Fatalf("%q: key %q of %d maps back to %d, %v", test, key, n, m, err)
+			}
+		}
+	}
+
+	// the shards are a partition of the records, whatever the thread count.
+	load, _ := newKeyFormat(properties.MustLoadString("keyformat={shard}/{num}\nkeyshards=4\nthreadcount=1"))
+	run, _ := newKeyFormat(properties.MustLoadString("keyformat={shard}/{num}\nkeyshards=4\nthreadcount=16"))
+	if load.key(13) != run.key(13) || load.key(13) != "1/13" {
+		t.Fatalf("key %q of the load differs from key %q of the run", load.key(13), run.key(13))
+	}
+
+	for _, bad := range []string{"keyformat=user{name}", "keyformat=user{tenant}", "keyformat=user{num", "keylength=10"} {
+		if _, err := newKeyFormat(properties.MustLoadString(bad)); err == nil {
+			t.Fatalf("%q: expect an error", bad)
+		}
+	}
+}
@@ -0,0 +1,339 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/generator"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+const leaseStateKey = contextKey("lease")
+
+const (
+	leaseKeepAlive = iota
+	leasePut
+	leaseRevoke
+	leaseExpire
+)
+
+type lease struct {
+	id   int64
+	ttl  time.Duration
+	keys []string
+	// renewed is when the last grant or keep-alive was sent, the lease
+	// expires ttl after it.
+	renewed time.Time
+	// checked is when a lease left to expire was last read.
+	checked time.Time
+}
+
+func (l *lease) expiry() time.Time {
+	return l.renewed.Add(l.ttl)
+}
+
+type leaseState struct {
+	// count is the number of live leases held by the thread.
+	count int
+	// live holds the leases kept alive, the least recently renewed first.
+	live []*lease
+	// expiring holds the leases left to expire, the earliest expiry first.
+	expiring []*lease
+}
+
+// take removes the i-th live lease.
+func (s *leaseState) take(i int) *lease {
+	l := s.live[i]
+	s.live = append(s.live[:i], s.live[i+1:]...)
+	return l
+}
+
+// leave stops renewing the i-th live lease.
+func (s *leaseState) leave(i int) {
+	s.expire(s.take(i))
+}
+
+// expire adds a lease no longer renewed to the expiring ones.
+func (s *leaseState) expire(l *lease) {
+	j := sort.Search(len(s.expiring), func(j int) bool {
+		return s.expiring[j].expiry().After(l.expiry())
+	})
+	s.expiring = append(s.expiring, nil)
+	copy(s.expiring[j+1:], s.expiring[j:])
+	s.expiring[j] = l
+}
+
+// leaseWorkload holds lease.count live leases with lease.keys keys each,
+// like the registrations of a service discovery or the locks of a lock
+// service. Every operation keeps alive, writes a key of, revokes or stops
+// renewing one of the leases of the thread, and a lease revoked or left to
+// expire is replaced by a new one. The leases are kept alive at a third of
+// their TTL as the etcd client does.
+//
+// A lease left to expire is read from its expiry on, every
+// lease.expirycheckinterval, and the delay until its keys are gone is
+// measured as LEASE_EXPIRY_DELAY.
+type leaseWorkload struct {
+	*core
+
+	leaseCount          int64
+	ttl                 time.Duration
+	keysPerLease        int64
+	expiryCheckInterval time.Duration
+	leaseChooser        *generator.Discrete
+	doTransactions      bool
+
+	// lastKey is the number of the keys attached to the leases so far, they
+	// follow the loaded records.
+	lastKey int64
+}
+
+// InitThread implements the Workload InitThread interface.
+func (l *leaseWorkload) InitThread(ctx context.Context, threadID int, threadCount int) context.Context {
+	ctx = l.core.InitThread(ctx, threadID, threadCount)
+	n, i := int64(threadCount), int64(threadID)
+	state := &leaseState{count: int(l.leaseCount*(i+1)/n - l.leaseCount*i/n)}
+	return context.WithValue(ctx, leaseStateKey, state)
+}
+
+// CheckDB implements the DBCheckWorkload CheckDB interface.
+func (l *leaseWorkload) CheckDB(db ycsb.DB) error {
+	if l.doTransactions && !ycsb.Supports(db, (*ycsb.LeaseDB)(nil)) {
+		return fmt.Errorf("lease workload needs a DB implementing the LeaseDB interface")
+	}
+	return nil
+}
+
+// DoTransaction implements the Workload DoTransaction interface.
+func (l *leaseWorkload) DoTransaction(ctx context.Context, db ycsb.DB) error {
+	leaseDB, ok := db.(ycsb.LeaseDB)
+	if !ok {
+		return fmt.Errorf("the %T doesn't implement the LeaseDB interface", db)
+	}
+	state := ctx.Value(stateKey).(*coreState)
+	ls := ctx.Value(leaseStateKey).(*leaseState)
+	now := time.Now()
+
+	if len(ls.expiring) > 0 {
+		if e := ls.expiring[0]; now.After(e.expiry()) && now.Sub(e.checked) >= l.expiryCheckInterval {
+			return l.checkExpiry(ctx, db, ls)
+		}
+	}
+	if len(ls.live) < ls.count {
+		return l.grant(ctx, leaseDB, state, ls)
+	}
+	if len(ls.live) == 0 {
+		return nil
+	}
+	if oldest := ls.live[0]; now.Sub(oldest.renewed) >= oldest.ttl/3 {
+		return l.keepAlive(ctx, leaseDB, ls)
+	}
+
+	switch l.leaseChooser.Next(state.r) {
+	case leaseKeepAlive:
+		return l.keepAlive(ctx, leaseDB, ls)
+	case leasePut:
+		i := state.r.Intn(len(ls.live))
+		le := ls.live[i]
+		start := time.Now()
+		err := l.put(ctx, leaseDB, state, le, le.keys[state.r.Intn(len(le.keys))])
+		if errors.Is(err, ycsb.ErrLeaseNotFound) {
+			ls.take(i)
+		}
+		return l.lost(le, start, err)
+	case leaseRevoke:
+		le := ls.take(state.r.Intn(len(ls.live)))
+		start := time.Now()
+		if err := leaseDB.RevokeLease(ctx, le.id); err != nil {
+			return l.lost(le, start, err)
+		}
+		return nil
+	default:
+		ls.leave(state.r.Intn(len(ls.live)))
+		return nil
+	}
+}
+
+// DoBatchTransaction implements the Workload DoBatchTransaction interface.
+func (l *leaseWorkload) DoBatchTransaction(ctx context.Context, batchSize int, db ycsb.DB) error {
+	for i := 0; i < batchSize; i++ {
+		if err := l.DoTransaction(ctx, db); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// grant creates a lease and attaches its keys to it, measured as
+// LEASE_REGISTER.
+func (l *leaseWorkload) grant(ctx context.Context, db ycsb.LeaseDB, state *coreState, ls *leaseState) error {
+	start := time.Now()
+	id, ttl, err := db.GrantLease(ctx, l.ttl)
+	if err != nil {
+		return err
+	}
+
+	le := &lease{id: id, ttl: ttl, renewed: start}
+	for i := int64(0); i < l.keysPerLease; i++ {
+		keyNum := l.recordCount + atomic.AddInt64(&l.lastKey, 1) - 1
+		le.keys = append(le.keys, l.buildKeyName(keyNum))
+	}
+	for i, key := range le.keys {
+		putStart := time.Now()
+		err := l.put(ctx, db, state, le, key)
+		if err == nil {
+			continue
+		}
+		if errors.Is(err, ycsb.ErrLeaseNotFound) {
+			return l.lost(le, putStart, err)
+		}
+		// another lease replaces it, the keys put so far are revoked with
+		// it, or checked once it expires if the revoke fails too.
+		if db.RevokeLease(ctx, le.id) != nil && i > 0 {
+			le.keys = le.keys[:i]
+			ls.expire(le)
+		}
+		return err
+	}
+	ls.live = append(ls.live, le)
+	measurement.Measure("LEASE_REGISTER", start, time.Now().Sub(start))
+	return nil
+}
+
+// put writes a key attached to a lease.
+func (l *leaseWorkload) put(ctx context.Context, db ycsb.LeaseDB, state *coreState, le *lease, key string) error {
+	values := l.buildValues(state, key)
+	defer l.putValues(values)
+
+	return db.PutWithLease(ctx, l.table, key, values, le.id)
+}
+
+// keepAlive renews the least recently renewed live lease.
+func (l *leaseWorkload) keepAlive(ctx context.Context, db ycsb.LeaseDB, ls *leaseState) error {
+	le := ls.live[0]
+	start := time.Now()
+	err := db.KeepAliveLease(ctx, le.id)
+	if err == nil {
+		le.renewed = start
+		ls.live = append(ls.live[1:], le)
+		return nil
+	}
+	if errors.Is(err, ycsb.ErrLeaseNotFound) {
+		ls.take(0)
+	}
+	return l.lost(le, start, err)
+}
+
+// lost measures a live lease found expired at start as LEASE_LOST, with the
+// time since its expected expiry, or as LEASE_EXPIRED_EARLY with the time
+// left before it. The caller drops the lease. The other errors are returned.
+func (l *leaseWorkload) lost(le *lease, start time.Time, err error) error {
+	if !errors.Is(err, ycsb.ErrLeaseNotFound) {
+		return err
+	}
+	if expiry := le.expiry(); start.Before(expiry) {
+		measurement.Measure("LEASE_EXPIRED_EARLY", start, expiry.Sub(start))
+	} else {
+		measurement.Measure("LEASE_LOST", expiry, start.Sub(expiry))
+	}
+	return nil
+}
+
+// checkExpiry reads a key of the lease left to expire first. Once the key is
+// gone, the delay since the expected expiry is measured as
+// LEASE_EXPIRY_DELAY. A lease whose key is still there a TTL after its
+// expiry is given up and measured as LEASE_NOT_EXPIRED.
+func (l *leaseWorkload) checkExpiry(ctx context.Context, db ycsb.DB, ls *leaseState) error {
+	le := ls.expiring[0]
+	le.checked = time.Now()
+	values, err := db.Read(ycsb.WithExpectedMiss(ctx), l.table, le.keys[0], nil)
+	if err != nil && !isMiss(values, err) {
+		// the lease is checked again later.
+		return err
+	}
+	expiry := le.expiry()
+	delay := le.checked.Sub(expiry)
+	if !isMiss(values, err) {
+		if delay < le.ttl {
+			return nil
+		}
+		measurement.Measure("LEASE_NOT_EXPIRED", expiry, delay)
+	} else {
+		measurement.Measure("LEASE_EXPIRY_DELAY", expiry, delay)
+	}
+	ls.expiring = ls.expiring[1:]
+	return nil
+}
+
+type leaseWorkloadCreator struct {
+}
+
+// Create implements the WorkloadCreator Create interface.
+func (leaseWorkloadCreator) Create(p *properties.Properties) (ycsb.Workload, error) {
+	w, err := coreCreator{}.Create(p)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &leaseWorkload{
+		core:                w.(*core),
+		leaseCount:          p.GetInt64(prop.LeaseCount, prop.LeaseCountDefault),
+		ttl:                 p.GetParsedDuration(prop.LeaseTTL, prop.LeaseTTLDefault),
+		keysPerLease:        p.GetInt64(prop.LeaseKeys, prop.LeaseKeysDefault),
+		expiryCheckInterval: p.GetParsedDuration(prop.LeaseExpiryCheckInterval, prop.LeaseExpiryCheckIntervalDefault),
+		leaseChooser:        generator.NewDiscrete(),
+		doTransactions:      p.GetBool(prop.DoTransactions, true),
+	}
+	if l.ttl <= 0 || l.keysPerLease <= 0 {
+		return nil, fmt.Errorf("%s and %s must be positive", prop.LeaseTTL, prop.LeaseKeys)
+	}
+	threadCount := p.GetInt64(prop.ThreadCount, prop.ThreadCountDefault)
+	if l.doTransactions && l.leaseCount < threadCount {
+		return nil, fmt.Errorf("lease workload needs at least one lease per thread, but got %d leases for %d threads", l.leaseCount, threadCount)
+	}
+
+	proportions := []struct {
+		op         int64
+		proportion float64
+	}{
+		{leaseKeepAlive, p.GetFloat64(prop.LeaseKeepAliveProportion, prop.LeaseKeepAliveProportionDefault)},
+		{leasePut, p.GetFloat64(prop.LeasePutProportion, prop.LeasePutProportionDefault)},
+		{leaseRevoke, p.GetFloat64(prop.LeaseRevokeProportion, prop.LeaseRevokeProportionDefault)},
+		{leaseExpire, p.GetFloat64(prop.LeaseExpireProportion, prop.LeaseExpireProportionDefault)},
+	}
+	var total float64
+	for _, o := range proportions {
+		if o.proportion > 0 {
+			l.leaseChooser.Add(o.proportion, o.op)
+			total += o.proportion
+		}
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("lease workload needs a positive lease operation proportion")
+	}
+	return l, nil
+}
+
+func init() {
+	ycsb.RegisterWorkloadCreator("lease", leaseWorkloadCreator{})
+}
@@ -0,0 +1,205 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+type memLease struct {
+	expiry time.Time
+	ttl    time.Duration
+	table  string
+	keys   []string
+}
+
+// memLeaseDB is a memDB with leases, an expired lease deletes its keys on
+// the next access.
+type memLeaseDB struct {
+	*memDB
+
+	mu     sync.Mutex
+	leases map[int64]*memLease
+	lastID int64
+}
+
+func newMemLeaseDB() *memLeaseDB {
+	return &memLeaseDB{memDB: newMemDB(), leases: make(map[int64]*memLease)}
+}
+
+// lease returns the lease if it's live, with the expired leases deleted. It
+// must be called with the lock held.
+func (db *memLeaseDB) lease(ctx context.Context, id int64) *memLease {
+	now := time.Now()
+	for i, l := range db.leases {
+		if now.After(l.expiry) {
+			db.revoke(ctx, i)
+		}
+	}
+	return db.leases[id]
+}
+
+func (db *memLeaseDB) revoke(ctx context.Context, id int64) {
+	l := db.leases[id]
+	for _, key := range l.keys {
+		db.memDB.Delete(ctx, l.table, key)
+	}
+	delete(db.leases, id)
+}
+
+func (db *memLeaseDB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	db.mu.Lock()
+	db.lease(ctx, 0)
+	db.mu.Unlock()
+	return db.memDB.Read(ctx, table, key, fields)
+}
+
+func (db *memLeaseDB) GrantLease(_ context.Context, ttl time.Duration) (int64, time.Duration, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.lastID++
+	db.leases[db.lastID] = &memLease{expiry: time.Now().Add(ttl), ttl: ttl}
+	return db.lastID, ttl, nil
+}
+
+func (db *memLeaseDB) PutWithLease(ctx context.Context, table string, key string, values map[string][]byte, id int64) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	l := db.lease(ctx, id)
+	if l == nil {
+		return ycsb.ErrLeaseNotFound
+	}
+	l.table = table
+	l.keys = append(l.keys, key)
+	return db.memDB.Update(ctx, table, key, values)
+}
+
+func (db *memLeaseDB) KeepAliveLease(ctx context.Context, id int64) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	l := db.lease(ctx, id)
+	if l == nil {
+		return ycsb.ErrLeaseNotFound
+	}
+	l.expiry = time.Now().Add(l.ttl)
+	return nil
+}
+
+func (db *memLeaseDB) RevokeLease(ctx context.Context, id int64) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.lease(ctx, id) == nil {
+		return ycsb.ErrLeaseNotFound
+	}
+	db.revoke(ctx, id)
+	return nil
+}
+
+func TestLease(t *testing.T) {
+	p := properties.MustLoadString("recordcount=10\nthreadcount=1\nlease.count=1\nlease.keys=2\nlease.ttl=200ms\nlease.expirycheckinterval=10ms")
+	measurement.InitMeasure(p)
+	w, err := leaseWorkloadCreator{}.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := w.(*leaseWorkload)
+	db := newMemLeaseDB()
+	if err := l.CheckDB(db); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := l.InitThread(context.Background(), 0, 1)
+	state := ctx.Value(stateKey).(*coreState)
+	ls := ctx.Value(leaseStateKey).(*leaseState)
+	exists := func(key string) bool {
+		values, err := db.Read(ctx, l.table, key, nil)
+		return err == nil && len(values) > 0
+	}
+
+	// the first operation grants the lease and puts its keys.
+	if err := l.DoTransaction(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	if len(ls.live) != 1 {
+		t.Fatalf("expect one live lease, got %d", len(ls.live))
+	}
+	le := ls.live[0]
+	for _, key := range le.keys {
+		if !exists(key) {
+			t.Fatalf("key %s of the lease is not put", key)
+		}
+	}
+
+	if err := l.put(ctx, db, state, le, le.keys[1]); err != nil {
+		t.Fatal(err)
+	}
+	renewed := le.renewed
+	time.Sleep(time.Millisecond)
+	if err := l.keepAlive(ctx, db, ls); err != nil {
+		t.Fatal(err)
+	}
+	if !le.renewed.After(renewed) || len(ls.live) != 1 {
+		t.Fatal("the keep-alive doesn't renew the lease")
+	}
+
+	// a revoked lease is lost for the next operations.
+	if err := db.RevokeLease(ctx, le.id); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.keepAlive(ctx, db, ls); err != nil {
+		t.Fatal(err)
+	}
+	if len(ls.live) != 0 || len(ls.expiring) != 0 {
+		t.Fatal("the keep-alive of a revoked lease must drop it")
+	}
+
+	// a lease left to expire is checked until its keys are gone.
+	if err := l.DoTransaction(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	le = ls.live[0]
+	ls.leave(0)
+	for len(ls.expiring) > 0 {
+		time.Sleep(l.expiryCheckInterval)
+		if err := l.checkExpiry(ctx, db, ls); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if delay := time.Since(le.expiry()); delay >= le.ttl {
+		t.Fatalf("the expiry is checked %s after it, expect less than the TTL", delay)
+	}
+	if exists(le.keys[0]) || exists(le.keys[1]) {
+		t.Fatal("the keys of the expired lease are still there")
+	}
+}
+
+func TestLeaseBadProperties(t *testing.T) {
+	for _, s := range []string{
+		"lease.ttl=0s",
+		"lease.keys=0",
+		"threadcount=2\nlease.count=1",
+		"lease.keepaliveproportion=0\nlease.putproportion=0\nlease.revokeproportion=0\nlease.expireproportion=0",
+	} {
+		if _, err := (leaseWorkloadCreator{}).Create(properties.MustLoadString(s)); err == nil {
+			t.Fatalf("expect %q to be rejected", s)
+		}
+	}
+}
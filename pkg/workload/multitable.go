@@ -0,0 +1,255 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/generator"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+const multiTableStateKey = contextKey("multitable")
+
+type multiTableState struct {
+	r *rand.Rand
+	// states holds the core state of the thread of every table.
+	states []*coreState
+}
+
+// multiTableWorkload runs a core workload on every table of
+// multitable.tables. A table takes its properties from the global ones
+// overridden by the ones prefixed with "table.<name>.", so every table has
+// its own record count, fields, operation proportions and request
+// distribution. The operations of the run phase are spread over the tables
+// by their share, and measured per table as "<name>.<operation>" too.
+type multiTableWorkload struct {
+	tables []*core
+	// loadEnds holds the cumulative load counts of the tables, the load
+	// phase inserts the records of the tables one after another.
+	loadEnds []int64
+	loaded   int64
+	chooser  *generator.Discrete
+}
+
+// InitThread implements the Workload InitThread interface.
+func (m *multiTableWorkload) InitThread(ctx context.Context, threadID int, threadCount int) context.Context {
+	state := &multiTableState{
+		r:      rand.New(rand.NewSource(time.Now().UnixNano())),
+		states: make([]*coreState, len(m.tables)),
+	}
+	for i, t := range m.tables {
+		state.states[i] = t.newState(threadID, threadCount)
+	}
+	return context.WithValue(ctx, multiTableStateKey, state)
+}
+
+// CleanupThread implements the Workload CleanupThread interface.
+func (m *multiTableWorkload) CleanupThread(_ context.Context) {
+}
+
+// Close implements the Workload Close interface.
+func (m *multiTableWorkload) Close() error {
+	for _, t := range m.tables {
+		if err := t.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Load implements the Workload Load interface.
+func (m *multiTableWorkload) Load(ctx context.Context, db ycsb.DB, totalCount int64) error {
+	return nil
+}
+
+// tableContext returns the context to run an operation on table i with.
+func (m *multiTableWorkload) tableContext(ctx context.Context, i int) context.Context {
+	state := ctx.Value(multiTableStateKey).(*multiTableState)
+	ctx = context.WithValue(ctx, stateKey, state.states[i])
+	return ycsb.WithMeasureGroup(ctx, m.tables[i].table)
+}
+
+// nextLoad reserves up to count records to load, all of one table, and
+// returns the table and how many records it reserved.
+func (m *multiTableWorkload) nextLoad(count int64) (int, int64, error) {
+	for {
+		n := atomic.LoadInt64(&m.loaded)
+		i := sort.Search(len(m.loadEnds), func(i int) bool { return m.loadEnds[i] > n })
+		if i == len(m.loadEnds) {
+			return 0, 0, fmt.Errorf("all the %d records of the tables are loaded", n)
+		}
+		end := n + count
+		if end > m.loadEnds[i] {
+			end = m.loadEnds[i]
+		}
+		if atomic.CompareAndSwapInt64(&m.loaded, n, end) {
+			return i, end - n, nil
+		}
+	}
+}
+
+// DoInsert implements the Workload DoInsert interface.
+func (m *multiTableWorkload) DoInsert(ctx context.Context, db ycsb.DB) error {
+	i, _, err := m.nextLoad(1)
+	if err != nil {
+		return err
+	}
+	return m.tables[i].DoInsert(m.tableContext(ctx, i), db)
+}
+
+// DoBatchInsert implements the Workload DoBatchInsert interface.
+func (m *multiTableWorkload) DoBatchInsert(ctx context.Context, batchSize int, db ycsb.DB) error {
+	for batchSize > 0 {
+		i, count, err := m.nextLoad(int64(batchSize))
+		if err != nil {
+			return err
+		}
+		if err = m.tables[i].DoBatchInsert(m.tableContext(ctx, i), int(count), db); err != nil {
+			return err
+		}
+		batchSize -= int(count)
+	}
+	return nil
+}
+
+func (m *multiTableWorkload) nextTable(ctx context.Context) int {
+	state := ctx.Value(multiTableStateKey).(*multiTableState)
+	return int(m.chooser.Next(state.r))
+}
+
+// CheckDB implements the DBCheckWorkload CheckDB interface.
+func (m *multiTableWorkload) CheckDB(db ycsb.DB) error {
+	for _, t := range m.tables {
+		if err := t.CheckDB(db); err != nil {
+			return fmt.Errorf("table %s: %v", t.table, err)
+		}
+	}
+	return nil
+}
+
+// DoTransaction implements the Workload DoTransaction interface.
+func (m *multiTableWorkload) DoTransaction(ctx context.Context, db ycsb.DB) error {
+	i := m.nextTable(ctx)
+	return m.tables[i].DoTransaction(m.tableContext(ctx, i), db)
+}
+
+// DoBatchTransaction implements the Workload DoBatchTransaction interface.
+func (m *multiTableWorkload) DoBatchTransaction(ctx context.Context, batchSize int, db ycsb.DB) error {
+	i := m.nextTable(ctx)
+	return m.tables[i].DoBatchTransaction(m.tableContext(ctx, i), batchSize, db)
+}
+
+// Verify implements the VerifyWorkload Verify interface, it verifies the
+// tables one after another and prefixes the sample keys with their table.
+func (m *multiTableWorkload) Verify(ctx context.Context, dbs []ycsb.DB) (*ycsb.VerifyReport, error) {
+	samples := m.tables[0].p.GetInt(prop.VerifySamples, prop.VerifySamplesDefault)
+	report := &ycsb.VerifyReport{}
+	for _, t := range m.tables {
+		r, err := t.Verify(ctx, dbs)
+		if err != nil {
+			return nil, fmt.Errorf("table %s: %v", t.table, err)
+		}
+		for _, issues := range []*ycsb.VerifyIssues{&r.Missing, &r.Extra, &r.Corrupted} {
+			for i, key := range issues.Samples {
+				issues.Samples[i] = t.table + ":" + key
+			}
+		}
+		report.Merge(r, samples)
+	}
+	return report, nil
+}
+
+type multiTableCreator struct {
+}
+
+// Create implements the WorkloadCreator Create interface.
+func (multiTableCreator) Create(p *properties.Properties) (ycsb.Workload, error) {
+	var names []string
+	for _, name := range strings.Split(p.GetString(prop.MultiTableTables, ""), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no table in %s", prop.MultiTableTables)
+	}
+
+	// The tables without a record count share the rest of the global one.
+	overlays := make([]*properties.Properties, len(names))
+	rest := p.GetInt64(prop.RecordCount, prop.RecordCountDefault)
+	var implicit []int
+	for i, name := range names {
+		overlays[i] = p.FilterStripPrefix(prop.MultiTablePrefix + name + ".")
+		if _, ok := overlays[i].Get(prop.RecordCount); ok {
+			rest -= overlays[i].GetInt64(prop.RecordCount, 0)
+		} else {
+			implicit = append(implicit, i)
+		}
+	}
+	if len(implicit) == 0 && rest != 0 {
+		return nil, fmt.Errorf("%s must be the total record count of the tables, %d records more or less",
+			prop.RecordCount, rest)
+	}
+	for j, i := range implicit {
+		count := rest / int64(len(implicit))
+		if j == len(implicit)-1 {
+			count = rest - count*int64(len(implicit)-1)
+		}
+		overlays[i].Set(prop.RecordCount, strconv.FormatInt(count, 10))
+	}
+
+	m := &multiTableWorkload{chooser: generator.NewDiscrete()}
+	var loadEnd int64
+	for i, name := range names {
+		tp := properties.NewProperties()
+		tp.Merge(p)
+		tp.Set(prop.TableName, name)
+		tp.Merge(overlays[i])
+		if tp.GetInt64(prop.RecordCount, 0) <= 0 {
+			return nil, fmt.Errorf("table %s has no record, set %s%s.%s", name, prop.MultiTablePrefix, name, prop.RecordCount)
+		}
+
+		w, err := coreCreator{}.Create(tp)
+		if err != nil {
+			return nil, err
+		}
+		t := w.(*core)
+		m.tables = append(m.tables, t)
+
+		insertStart := tp.GetInt64(prop.InsertStart, prop.InsertStartDefault)
+		loadEnd += tp.GetInt64(prop.InsertCount, t.recordCount-insertStart)
+		m.loadEnds = append(m.loadEnds, loadEnd)
+
+		share := tp.GetFloat64(prop.MultiTableShare, prop.MultiTableShareDefault)
+		if share < 0 {
+			return nil, fmt.Errorf("share %v of table %s must not be negative", share, name)
+		}
+		m.chooser.Add(share, int64(i))
+	}
+	return m, nil
+}
+
+func init() {
+	ycsb.RegisterWorkloadCreator("multitable", multiTableCreator{})
+}
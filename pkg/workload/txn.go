@@ -0,0 +1,195 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// txnWorkload runs transactions of txn.readcount reads and txn.writecount
+// updates on random keys. It loads the data and chooses the keys like the
+// core workload.
+type txnWorkload struct {
+	*core
+
+	readCount      int64
+	writeCount     int64
+	maxRetries     int64
+	doTransactions bool
+}
+
+// CheckDB implements the DBCheckWorkload CheckDB interface.
+func (t *txnWorkload) CheckDB(db ycsb.DB) error {
+	if t.doTransactions && !ycsb.Supports(db, (*ycsb.TxnDB)(nil)) {
+		return fmt.Errorf("txn workload needs a DB implementing the TxnDB interface")
+	}
+	return nil
+}
+
+// DoTransaction implements the Workload DoTransaction interface.
+func (t *txnWorkload) DoTransaction(ctx context.Context, db ycsb.DB) error {
+	txnDB, ok := db.(ycsb.TxnDB)
+	if !ok {
+		return fmt.Errorf("the %T doesn't implement the TxnDB interface", db)
+	}
+	state := ctx.Value(stateKey).(*coreState)
+	keys := t.nextTxnKeys(state)
+
+	start := time.Now()
+	for retry := int64(0); ; retry++ {
+		if retry > 0 {
+			measurement.Measure("TXN_RETRY", start, time.Now().Sub(start))
+		}
+
+		attemptStart := time.Now()
+		err := t.doTxn(ctx, txnDB, state, keys)
+		if err != nil && !errors.Is(err, ycsb.ErrTxnConflict) {
+			return err
+		}
+		measurement.Measure("TXN_ATTEMPT", attemptStart, time.Now().Sub(attemptStart))
+		if err == nil {
+			measurement.Measure("TXN", start, time.Now().Sub(start))
+			return nil
+		}
+
+		measurement.Measure("TXN_CONFLICT", start, time.Now().Sub(start))
+		if retry >= t.maxRetries {
+			measurement.Measure("TXN_ABORT", start, time.Now().Sub(start))
+			measurement.Measure("TXN", start, time.Now().Sub(start))
+			return nil
+		}
+	}
+}
+
+// DoBatchTransaction implements the Workload DoBatchTransaction interface.
+func (t *txnWorkload) DoBatchTransaction(ctx context.Context, batchSize int, db ycsb.DB) error {
+	for i := 0; i < batchSize; i++ {
+		if err := t.DoTransaction(ctx, db); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nextTxnKeys returns the distinct keys read first and then updated by one
+// transaction.
+func (t *txnWorkload) nextTxnKeys(state *coreState) []string {
+	n := t.readCount + t.writeCount
+	if n > t.recordCount {
+		n = t.recordCount
+	}
+
+	keys := make([]string, 0, n)
+	seen := make(map[int64]struct{}, n)
+	// Give up on distinct keys after enough attempts, a skewed distribution
+	// may keep picking the same few keys.
+	for i := int64(0); int64(len(keys)) < n && i < 10*n; i++ {
+		keyNum := t.nextKeyNum(state)
+		if _, ok := seen[keyNum]; ok {
+			continue
+		}
+		seen[keyNum] = struct{}{}
+		keys = append(keys, t.buildKeyName(keyNum))
+	}
+	return keys
+}
+
+func (t *txnWorkload) doTxn(ctx context.Context, db ycsb.TxnDB, state *coreState, keys []string) (err error) {
+	txn, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			txn.Rollback(ctx)
+		}
+	}()
+
+	reads := int(t.readCount)
+	if reads > len(keys) {
+		reads = len(keys)
+	}
+
+	for _, key := range keys[:reads] {
+		var fields []string
+		if !t.readAllFields {
+			fields = []string{state.fieldNames[t.fieldChooser.Next(state.r)]}
+		}
+		start := time.Now()
+		values, err := txn.Read(ctx, t.table, key, fields)
+		if err != nil {
+			return err
+		}
+		if t.dataIntegrity && !t.verifyRow(key, values) {
+			measurement.Measure("INTEGRITY_ERROR", start, time.Now().Sub(start))
+		}
+	}
+
+	for _, key := range keys[reads:] {
+		var values map[string][]byte
+		if t.writeAllFields {
+			values = t.buildValues(state, key)
+		} else {
+			values = t.buildSingleValue(state, key)
+		}
+		err := txn.Update(ctx, t.table, key, values)
+		t.putValues(values)
+		if err != nil {
+			return err
+		}
+	}
+
+	return txn.Commit(ctx)
+}
+
+type txnWorkloadCreator struct {
+}
+
+// Create implements the WorkloadCreator Create interface.
+func (txnWorkloadCreator) Create(p *properties.Properties) (ycsb.Workload, error) {
+	w, err := coreCreator{}.Create(p)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &txnWorkload{
+		core:           w.(*core),
+		readCount:      p.GetInt64(prop.TxnReadCount, prop.TxnReadCountDefault),
+		writeCount:     p.GetInt64(prop.TxnWriteCount, prop.TxnWriteCountDefault),
+		maxRetries:     p.GetInt64(prop.TxnMaxRetries, prop.TxnMaxRetriesDefault),
+		doTransactions: p.GetBool(prop.DoTransactions, true),
+	}
+	if t.readCount < 0 || t.writeCount < 0 || t.readCount+t.writeCount == 0 {
+		util.Fatalf("%s and %s must not be negative and at least one of them must be positive",
+			prop.TxnReadCount, prop.TxnWriteCount)
+	}
+	if t.doTransactions {
+		measurement.ReportSuccessRate("TXN", "TXN_ABORT")
+		measurement.ReportSuccessRate("TXN_ATTEMPT", "TXN_CONFLICT")
+	}
+	return t, nil
+}
+
+func init() {
+	ycsb.RegisterWorkloadCreator("txn", txnWorkloadCreator{})
+}
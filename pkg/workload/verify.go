@@ -0,0 +1,204 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/session"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// rowChecker returns whether a record read from the database is corrupted.
+// The values are checked without the headers of the session check, the
+// databases are not wrapped.
+type rowChecker func(key string, values map[string][]byte) bool
+
+// corruptedRow returns whether a record misses some fields, or has a value
+// not written by the workload if the data integrity is checked.
+func (c *core) corruptedRow(key string, values map[string][]byte) bool {
+	if len(values) < len(c.fieldNames) {
+		return true
+	}
+	return c.dataIntegrity && !c.verifyRow(key, values)
+}
+
+// Verify implements the VerifyWorkload Verify interface.
+func (c *core) Verify(ctx context.Context, dbs []ycsb.DB) (*ycsb.VerifyReport, error) {
+	return c.verify(ctx, dbs, c.corruptedRow)
+}
+
+// verify checks that every record of [insertstart, insertstart+insertcount)
+// exists and passes check. It scans the table if the databases return the
+// keys of a scan, which finds the extra records too, and reads the records
+// one by one otherwise.
+func (c *core) verify(ctx context.Context, dbs []ycsb.DB, check rowChecker) (*ycsb.VerifyReport, error) {
+	if len(dbs) == 0 {
+		return nil, fmt.Errorf("no database to verify")
+	}
+	samples := c.p.GetInt(prop.VerifySamples, prop.VerifySamplesDefault)
+
+	_, canScan := dbs[0].(ycsb.KeyScanDB)
+	var scan bool
+	switch mode := c.p.GetString(prop.VerifyMode, prop.VerifyModeDefault); mode {
+	case "auto":
+		scan = canScan
+	case "scan":
+		if !canScan {
+			return nil, fmt.Errorf("the %T doesn't implement the KeyScanDB interface", dbs[0])
+		}
+		scan = true
+	case "read":
+	default:
+		return nil, fmt.Errorf("unknown verify mode %s", mode)
+	}
+
+	var s *keyScan
+	if scan {
+		s = c.newKeyScan()
+		// every thread scans a non-empty range of the keys
+		if len(s.keys) < len(dbs) {
+			dbs = dbs[:1]
+		}
+	}
+
+	reports := make([]*ycsb.VerifyReport, len(dbs))
+	errs := make([]error, len(dbs))
+	var wg sync.WaitGroup
+	for i, db := range dbs {
+		wg.Add(1)
+		go func(i int, db ycsb.DB) {
+			defer wg.Done()
+			ctx := db.InitThread(ctx, i, len(dbs))
+			defer db.CleanupThread(ctx)
+
+			reports[i] = &ycsb.VerifyReport{Scanned: scan}
+			if scan {
+				errs[i] = c.verifyScan(ctx, db.(ycsb.KeyScanDB), s, i, len(dbs), check, reports[i], samples)
+			} else {
+				errs[i] = c.verifyReads(ctx, db, i, len(dbs), check, reports[i], samples)
+			}
+		}(i, db)
+	}
+	wg.Wait()
+
+	report := &ycsb.VerifyReport{Scanned: scan}
+	for i, r := range reports {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		report.Merge(r, samples)
+	}
+	if scan {
+		report.Checked = int64(len(s.keys))
+		for i, seen := range s.seen {
+			if !seen {
+				report.Missing.Add(s.keys[i], samples)
+			}
+		}
+	}
+	return report, nil
+}
+
+// verifyReads reads the records of the i-th of n parts of the range, only
+// the records not found are missing, the other read errors fail the check.
+func (c *core) verifyReads(ctx context.Context, db ycsb.DB, i int, n int, check rowChecker,
+	report *ycsb.VerifyReport, samples int) error {
+	start := c.insertStart + c.insertCount*int64(i)/int64(n)
+	end := c.insertStart + c.insertCount*int64(i+1)/int64(n)
+	for keyNum := start; keyNum < end; keyNum++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		key := c.buildKeyName(keyNum)
+		report.Checked++
+		values, err := db.Read(ctx, c.table, key, nil)
+		if isMiss(values, err) {
+			report.Missing.Add(key, samples)
+		} else if err != nil {
+			return fmt.Errorf("read %s: %w", key, err)
+		} else if check(key, session.Strip(values)) {
+			report.Corrupted.Add(key, samples)
+		}
+	}
+	return nil
+}
+
+// keyScan holds the expected keys of a scan in key order.
+type keyScan struct {
+	keys  []string
+	index map[string]int
+	// seen marks the keys found, every thread marks its own range.
+	seen []bool
+}
+
+func (c *core) newKeyScan() *keyScan {
+	s := &keyScan{keys: make([]string, 0, c.insertCount)}
+	for keyNum := c.insertStart; keyNum < c.insertStart+c.insertCount; keyNum++ {
+		s.keys = append(s.keys, c.buildKeyName(keyNum))
+	}
+	sort.Strings(s.keys)
+	s.index = make(map[string]int, len(s.keys))
+	for i, key := range s.keys {
+		s.index[key] = i
+	}
+	s.seen = make([]bool, len(s.keys))
+	return s
+}
+
+// verifyScan scans the i-th of n parts of the table, split at the expected
+// keys. The first part starts at the beginning of the table and the last
+// one runs to its end, so the extra keys outside the range are found too.
+func (c *core) verifyScan(ctx context.Context, db ycsb.KeyScanDB, s *keyScan, i int, n int, check rowChecker,
+	report *ycsb.VerifyReport, samples int) error {
+	var from, to string
+	if i > 0 {
+		from = s.keys[len(s.keys)*i/n]
+	}
+	if i < n-1 {
+		to = s.keys[len(s.keys)*(i+1)/n]
+	}
+
+	count := c.p.GetInt(prop.VerifyScanCount, prop.VerifyScanCountDefault)
+	for {
+		keys, rows, err := db.ScanKeys(ctx, c.table, from, count, nil)
+		if err != nil {
+			return err
+		}
+		for j, key := range keys {
+			if to != "" && key >= to {
+				return nil
+			}
+			k, ok := s.index[key]
+			if !ok {
+				report.Extra.Add(key, samples)
+				continue
+			}
+			s.seen[k] = true
+			if check(key, session.Strip(rows[j])) {
+				report.Corrupted.Add(key, samples)
+			}
+		}
+		if len(keys) < count {
+			return nil
+		}
+		// the smallest key after the last one
+		from = keys[len(keys)-1] + "\x00"
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"context"
+	"testing"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/session"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+func TestVerifySessionStampedValues(t *testing.T) {
+	p := properties.MustLoadString("recordcount=10\ndataintegrity=true\nsession.check=true")
+	w, err := coreCreator{}.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := w.(*core)
+	db := newMemDB()
+
+	ctx := c.InitThread(context.Background(), 0, 1)
+	for i := 0; i < 10; i++ {
+		if err := c.DoInsert(ctx, db); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c.CleanupThread(ctx)
+
+	// the run updated a record with the session header, and corrupted another.
+	session.Init(p)
+	s := session.FromContext(session.NewContext(context.Background()))
+	state := c.InitThread(context.Background(), 0, 1).Value(stateKey).(*coreState)
+	updated, corrupted := c.buildKeyName(3), c.buildKeyName(7)
+	values, _ := s.Stamp(c.buildValues(state, updated))
+	if err := db.Update(ctx, c.table, updated, values); err != nil {
+		t.Fatal(err)
+	}
+	values, _ = s.Stamp(map[string][]byte{c.fieldNames[0]: []byte("corrupted")})
+	if err := db.Update(ctx, c.table, corrupted, values); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := c.Verify(context.Background(), []ycsb.DB{db})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Checked != 10 || report.Missing.Count != 0 {
+		t.Fatalf("expect 10 records found, got %d checked and %d missing", report.Checked, report.Missing.Count)
+	}
+	if report.Corrupted.Count != 1 || report.Corrupted.Samples[0] != corrupted {
+		t.Fatalf("expect only %s to be corrupted, got %v", corrupted, report.Corrupted.Samples)
+	}
+}
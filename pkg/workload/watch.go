@@ -0,0 +1,367 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+const (
+	watchStateKey = contextKey("watch")
+	// watchStampField holds the writer, the sequence number and the send
+	// time of a write, as "writer:seq:unixnano".
+	watchStampField = "watchstamp"
+)
+
+type watchState struct {
+	threadID    int
+	threadCount int
+	started     bool
+}
+
+// watchWriter logs the writes of a thread, indexed by their sequence
+// number, the watchers look up the writes of the events in it.
+type watchWriter struct {
+	sync.Mutex
+	sent []time.Time
+	// failed marks the writes which returned an error, they may or may not
+	// be delivered.
+	failed []bool
+	// delivered counts the watchers which received every write.
+	delivered []int
+}
+
+// reserve logs a write about to be sent, before the watchers may get it,
+// and returns its sequence number and send time.
+func (w *watchWriter) reserve() (int, time.Time) {
+	w.Lock()
+	defer w.Unlock()
+	sent := time.Now()
+	w.sent = append(w.sent, sent)
+	w.failed = append(w.failed, false)
+	w.delivered = append(w.delivered, 0)
+	return len(w.failed) - 1, sent
+}
+
+func (w *watchWriter) fail(seq int) {
+	w.Lock()
+	w.failed[seq] = true
+	w.Unlock()
+}
+
+// deliver counts a delivery of the write and returns the number of the
+// watchers which received it, or false if the write is unknown.
+func (w *watchWriter) deliver(seq int) (int, bool) {
+	w.Lock()
+	defer w.Unlock()
+	if seq >= len(w.delivered) {
+		return 0, false
+	}
+	w.delivered[seq]++
+	return w.delivered[seq], true
+}
+
+func formatWatchStamp(writer int, seq int, sent time.Time) []byte {
+	return []byte(fmt.Sprintf("%d:%d:%d", writer, seq, sent.UnixNano()))
+}
+
+func parseWatchStamp(stamp []byte) (writer int, seq int, sent time.Time, err error) {
+	parts := strings.Split(string(stamp), ":")
+	if len(parts) != 3 {
+		return 0, 0, time.Time{}, fmt.Errorf("invalid watch stamp %q", stamp)
+	}
+	if writer, err = strconv.Atoi(parts[0]); err != nil {
+		return 0, 0, time.Time{}, err
+	}
+	if seq, err = strconv.Atoi(parts[1]); err != nil {
+		return 0, 0, time.Time{}, err
+	}
+	nanos, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return 0, 0, time.Time{}, err
+	}
+	return writer, seq, time.Unix(0, nanos), nil
+}
+
+// watchWorkload measures the latency of the change notifications: every
+// thread updates the records chosen by the request distribution, stamped
+// with their send time, while watch.watchers goroutines watch the table on
+// the connections of the threads. The writes start once all the watches
+// are established, so every watcher must receive every write.
+//
+// Every event is measured as WATCH_EVENT from the send time of its write,
+// and every write as WATCH_FANOUT once the last watcher received it, which
+// shows how the delivery scales with the number of watchers. A write
+// received twice by a watcher is measured as WATCH_DUPLICATE. After the
+// run, the watchers get watch.draintimeout to receive the events in flight,
+// and every event a watcher never received is measured as WATCH_DROPPED,
+// from the send time of its write.
+type watchWorkload struct {
+	*core
+
+	watchers       int
+	drainTimeout   time.Duration
+	doTransactions bool
+
+	initOnce sync.Once
+	writers  []*watchWriter
+	// ready is closed once every watcher is established or failed.
+	ready          chan struct{}
+	readyWatchers  int64
+	runningThreads int64
+	// started is set once a thread started its watchers, the load phase
+	// doesn't watch.
+	started int32
+
+	watchersDone sync.WaitGroup
+	mu           sync.Mutex
+	cancels      []context.CancelFunc
+	// seen holds the writes received by every watcher, per writer.
+	seen [][][]bool
+
+	// expected counts the deliveries due for the successful writes, and
+	// received the deliveries so far.
+	expected   int64
+	received   int64
+	duplicates int64
+}
+
+// InitThread implements the Workload InitThread interface.
+func (w *watchWorkload) InitThread(ctx context.Context, threadID int, threadCount int) context.Context {
+	w.initOnce.Do(func() {
+		w.writers = make([]*watchWriter, threadCount)
+		for i := range w.writers {
+			w.writers[i] = new(watchWriter)
+		}
+		w.ready = make(chan struct{})
+		w.runningThreads = int64(threadCount)
+		w.seen = make([][][]bool, w.watchers)
+	})
+	ctx = w.core.InitThread(ctx, threadID, threadCount)
+	return context.WithValue(ctx, watchStateKey, &watchState{threadID: threadID, threadCount: threadCount})
+}
+
+// CleanupThread implements the Workload CleanupThread interface, the last
+// thread stops the watchers and reports the deliveries.
+func (w *watchWorkload) CleanupThread(ctx context.Context) {
+	if atomic.AddInt64(&w.runningThreads, -1) != 0 || atomic.LoadInt32(&w.started) == 0 {
+		return
+	}
+
+	deadline := time.Now().Add(w.drainTimeout)
+	for atomic.LoadInt64(&w.received) < atomic.LoadInt64(&w.expected) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	w.mu.Lock()
+	for _, cancel := range w.cancels {
+		cancel()
+	}
+	w.mu.Unlock()
+	w.watchersDone.Wait()
+
+	var writes, dropped int64
+	now := time.Now()
+	for writer, ww := range w.writers {
+		for seq, failed := range ww.failed {
+			if failed {
+				continue
+			}
+			writes++
+			for _, seen := range w.seen {
+				if seq >= len(seen[writer]) || !seen[writer][seq] {
+					dropped++
+					measurement.Measure("WATCH_DROPPED", ww.sent[seq], now.Sub(ww.sent[seq]))
+				}
+			}
+		}
+	}
+	fmt.Printf("watch: %d writes to %d watchers, %d events dropped, %d duplicated\n",
+		writes, w.watchers, dropped, atomic.LoadInt64(&w.duplicates))
+}
+
+// CheckDB implements the DBCheckWorkload CheckDB interface.
+func (w *watchWorkload) CheckDB(db ycsb.DB) error {
+	if w.doTransactions && !ycsb.Supports(db, (*ycsb.WatchDB)(nil)) {
+		return fmt.Errorf("watch workload needs a DB implementing the WatchDB interface")
+	}
+	return nil
+}
+
+// DoTransaction implements the Workload DoTransaction interface.
+func (w *watchWorkload) DoTransaction(ctx context.Context, db ycsb.DB) error {
+	ws := ctx.Value(watchStateKey).(*watchState)
+	if !ws.started {
+		ws.started = true
+		atomic.StoreInt32(&w.started, 1)
+		watchDB, ok := db.(ycsb.WatchDB)
+		if !ok {
+			return fmt.Errorf("the %T doesn't implement the WatchDB interface", db)
+		}
+		// the watchers are spread over the connections of the threads.
+		for id := ws.threadID; id < w.watchers; id += ws.threadCount {
+			watchCtx, cancel := context.WithCancel(ctx)
+			w.mu.Lock()
+			w.cancels = append(w.cancels, cancel)
+			w.mu.Unlock()
+			w.watchersDone.Add(1)
+			go w.watch(watchCtx, watchDB, id, ws.threadCount)
+		}
+		select {
+		case <-w.ready:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	state := ctx.Value(stateKey).(*coreState)
+	keyName := w.buildKeyName(w.nextKeyNum(state))
+	values := w.buildValues(state, keyName)
+	defer w.putValues(values)
+
+	writer := w.writers[ws.threadID]
+	seq, sent := writer.reserve()
+	values[watchStampField] = formatWatchStamp(ws.threadID, seq, sent)
+	err := db.Update(ctx, w.table, keyName, values)
+	// the stamp is not a pooled buffer.
+	delete(values, watchStampField)
+	if err != nil {
+		writer.fail(seq)
+		return err
+	}
+	atomic.AddInt64(&w.expected, int64(w.watchers))
+	return nil
+}
+
+// DoBatchTransaction implements the Workload DoBatchTransaction interface.
+func (w *watchWorkload) DoBatchTransaction(ctx context.Context, batchSize int, db ycsb.DB) error {
+	for i := 0; i < batchSize; i++ {
+		if err := w.DoTransaction(ctx, db); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// markReady counts an established or failed watcher.
+func (w *watchWorkload) markReady() {
+	if atomic.AddInt64(&w.readyWatchers, 1) == int64(w.watchers) {
+		close(w.ready)
+	}
+}
+
+// watch receives the events of the table until ctx is canceled, and watches
+// again after the watch fails. The events missed meanwhile are dropped.
+func (w *watchWorkload) watch(ctx context.Context, db ycsb.WatchDB, id int, writers int) {
+	defer w.watchersDone.Done()
+	seen := make([][]bool, writers)
+	defer func() {
+		w.mu.Lock()
+		w.seen[id] = seen
+		w.mu.Unlock()
+	}()
+
+	ready := false
+	for ctx.Err() == nil {
+		ch, err := db.Watch(ctx, w.table)
+		if !ready {
+			ready = true
+			w.markReady()
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				fmt.Printf("watcher %d failed: %v\n", id, err)
+			}
+			return
+		}
+
+		for ev := range ch {
+			if ev.Err != nil {
+				fmt.Printf("watcher %d watches again after: %v\n", id, ev.Err)
+				break
+			}
+			w.receive(ev, seen, time.Now())
+		}
+	}
+}
+
+// receive measures the delivery of an event, the events without a stamp of
+// this run are ignored.
+func (w *watchWorkload) receive(ev ycsb.WatchEvent, seen [][]bool, received time.Time) {
+	stamp, ok := ev.Values[watchStampField]
+	if !ok {
+		return
+	}
+	writer, seq, sent, err := parseWatchStamp(stamp)
+	if err != nil || writer < 0 || writer >= len(seen) || seq < 0 {
+		return
+	}
+
+	latency := received.Sub(sent)
+	if seq < len(seen[writer]) && seen[writer][seq] {
+		atomic.AddInt64(&w.duplicates, 1)
+		measurement.Measure("WATCH_DUPLICATE", sent, latency)
+		return
+	}
+	delivered, ok := w.writers[writer].deliver(seq)
+	if !ok {
+		return
+	}
+	for len(seen[writer]) <= seq {
+		seen[writer] = append(seen[writer], false)
+	}
+	seen[writer][seq] = true
+	atomic.AddInt64(&w.received, 1)
+
+	measurement.Measure("WATCH_EVENT", sent, latency)
+	if delivered == w.watchers {
+		measurement.Measure("WATCH_FANOUT", sent, latency)
+	}
+}
+
+type watchWorkloadCreator struct {
+}
+
+// Create implements the WorkloadCreator Create interface.
+func (watchWorkloadCreator) Create(p *properties.Properties) (ycsb.Workload, error) {
+	w, err := coreCreator{}.Create(p)
+	if err != nil {
+		return nil, err
+	}
+
+	ww := &watchWorkload{
+		core:           w.(*core),
+		watchers:       int(p.GetInt64(prop.WatchWatchers, prop.WatchWatchersDefault)),
+		drainTimeout:   p.GetParsedDuration(prop.WatchDrainTimeout, prop.WatchDrainTimeoutDefault),
+		doTransactions: p.GetBool(prop.DoTransactions, true),
+	}
+	if ww.watchers <= 0 {
+		return nil, fmt.Errorf("%s must be positive", prop.WatchWatchers)
+	}
+	return ww, nil
+}
+
+func init() {
+	ycsb.RegisterWorkloadCreator("watch", watchWorkloadCreator{})
+}
@@ -0,0 +1,80 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ycsb
+
+import "context"
+
+type contextKey string
+
+const (
+	expectedMissKey = contextKey("expectedMiss")
+	measureGroupKey = contextKey("measureGroup")
+	userSessionKey  = contextKey("userSession")
+)
+
+// WithExpectedMiss returns a context telling the DB layer that the record
+// accessed with it may have been deleted, so failing to find it is not an error.
+func WithExpectedMiss(ctx context.Context) context.Context {
+	return context.WithValue(ctx, expectedMissKey, true)
+}
+
+// IsExpectedMiss returns whether the context was created by WithExpectedMiss.
+func IsExpectedMiss(ctx context.Context) bool {
+	v, _ := ctx.Value(expectedMissKey).(bool)
+	return v
+}
+
+// WithMeasureGroup returns a context telling the DB layer to measure the
+// operations accessed with it under group as well, e.g. "users.READ" besides
+// "READ" for the group "users".
+func WithMeasureGroup(ctx context.Context, group string) context.Context {
+	return context.WithValue(ctx, measureGroupKey, group)
+}
+
+// MeasureGroup returns the group set by WithMeasureGroup, or "".
+func MeasureGroup(ctx context.Context) string {
+	v, _ := ctx.Value(measureGroupKey).(string)
+	return v
+}
+
+// UserSession is the session of a virtual user of the client: a sequence of
+// operations on a related key set, followed by an idle gap.
+type UserSession struct {
+	// User is the ID of the virtual user.
+	User int64
+	// Op is the index of the current operation in the session.
+	Op int64
+	// KeyBase is the first record number chosen by the workload in the
+	// session, the related records follow it. It is -1 until the workload
+	// chooses it.
+	KeyBase int64
+}
+
+// Begin starts the next session of the user.
+func (s *UserSession) Begin() {
+	s.Op = 0
+	s.KeyBase = -1
+}
+
+// WithUserSession returns a context telling the workload the operations
+// accessed with it belong to the session s.
+func WithUserSession(ctx context.Context, s *UserSession) context.Context {
+	return context.WithValue(ctx, userSessionKey, s)
+}
+
+// GetUserSession returns the session set by WithUserSession, or nil.
+func GetUserSession(ctx context.Context) *UserSession {
+	s, _ := ctx.Value(userSessionKey).(*UserSession)
+	return s
+}
@@ -15,7 +15,10 @@ package ycsb
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"reflect"
+	"time"
 
 	"github.com/magiconair/properties"
 )
@@ -83,6 +86,13 @@ type BatchDB interface {
 	// fields: The list of fields to read, nil|empty for reading all.
 	BatchRead(ctx context.Context, table string, keys []string, fields []string) ([]map[string][]byte, error)
 
+	// BatchScan scans several ranges of records from the database.
+	// table: The name of the table.
+	// startKeys: The first record keys of the ranges.
+	// counts: The number of records to read of every range.
+	// fields: The list of fields to read, nil|empty for reading all.
+	BatchScan(ctx context.Context, table string, startKeys []string, counts []int, fields []string) ([][]map[string][]byte, error)
+
 	// BatchUpdate updates records in the database.
 	// table: The name of table.
 	// keys: The keys of records to update.
@@ -102,6 +112,152 @@ type AnalyzeDB interface {
 	Analyze(ctx context.Context, table string) error
 }
 
+// KeyScanDB is the interface for the DB whose scans return the keys of the
+// records too, so all the records of a table can be walked.
+type KeyScanDB interface {
+	// ScanKeys scans records of the table from the database in key order, and
+	// returns their keys and field/value maps.
+	// table: The name of the table.
+	// startKey: The first record key to read.
+	// count: The maximum number of records to read.
+	// fields: The list of fields to read, nil|empty for reading all.
+	ScanKeys(ctx context.Context, table string, startKey string, count int, fields []string) ([]string, []map[string][]byte, error)
+}
+
+// CASDB is the interface for the DB that supports conditional writes.
+type CASDB interface {
+	// ReadVersion reads a record and its version, which changes on every write
+	// of the record. A missing record is reported as by Read, with ErrNotFound
+	// or no values.
+	// table: The name of the table.
+	// key: The record key of the record to read.
+	// fields: The list of fields to read, nil|empty for reading all.
+	ReadVersion(ctx context.Context, table string, key string, fields []string) (map[string][]byte, int64, error)
+
+	// CompareAndSwap replaces the record with values if its version is still
+	// version, and returns whether it did.
+	// table: The name of the table.
+	// key: The record key of the record to write.
+	// version: The version returned by ReadVersion.
+	// values: A map of field/value pairs of the new record.
+	CompareAndSwap(ctx context.Context, table string, key string, version int64, values map[string][]byte) (bool, error)
+}
+
+// ErrLeaseNotFound is returned, possibly wrapped, by LeaseDB when the lease
+// has expired or has been revoked.
+var ErrLeaseNotFound = errors.New("lease not found")
+
+// LeaseDB is the interface for the DB whose records can be attached to a
+// lease, and are deleted when the lease expires or is revoked.
+type LeaseDB interface {
+	// GrantLease creates a lease expiring after ttl unless it is kept alive,
+	// and returns its ID and the TTL granted by the database, which may be
+	// longer than ttl.
+	GrantLease(ctx context.Context, ttl time.Duration) (int64, time.Duration, error)
+
+	// PutWithLease inserts or replaces a record attached to a lease.
+	// table: The name of the table.
+	// key: The record key of the record to write.
+	// values: A map of field/value pairs of the record.
+	// lease: The ID returned by GrantLease.
+	PutWithLease(ctx context.Context, table string, key string, values map[string][]byte, lease int64) error
+
+	// KeepAliveLease renews the lease once, it expires after its TTL again.
+	KeepAliveLease(ctx context.Context, lease int64) error
+
+	// RevokeLease revokes the lease and deletes the records attached to it.
+	RevokeLease(ctx context.Context, lease int64) error
+}
+
+// WatchEvent is a change of a record delivered by WatchDB.
+type WatchEvent struct {
+	// Key is the record key.
+	Key string
+	// Values holds the field/value pairs of the record, nil if it was deleted.
+	Values map[string][]byte
+	// Err is the error ending the watch, the channel is closed after it.
+	Err error
+}
+
+// WatchDB is the interface for the DB that notifies the changes of records.
+type WatchDB interface {
+	// Watch watches the changes of the records of the table, and returns once
+	// the watch is established. Every change committed later is delivered on
+	// the channel in commit order, until ctx is canceled or the watch fails.
+	// table: The name of the table.
+	Watch(ctx context.Context, table string) (<-chan WatchEvent, error)
+}
+
+// ErrNotFound is returned, possibly wrapped, by an operation needing an
+// existing record when the record is not found. A read may return no values
+// for a missing record instead.
+var ErrNotFound = errors.New("record not found")
+
+// ErrTxnConflict is returned, possibly wrapped, by Txn when the transaction
+// conflicts with another one and can be retried.
+var ErrTxnConflict = errors.New("transaction conflict")
+
+// TxnDB is the interface for the DB that supports multi-operation transactions.
+type TxnDB interface {
+	// Begin starts a transaction.
+	Begin(ctx context.Context) (Txn, error)
+}
+
+// Txn is a transaction started by TxnDB.Begin. The reads see a consistent
+// snapshot, and the writes become visible atomically when Commit succeeds.
+// Errors caused by a conflict with another transaction must wrap ErrTxnConflict.
+type Txn interface {
+	// Read reads a record in the transaction.
+	// table: The name of the table.
+	// key: The record key of the record to read.
+	// fields: The list of fields to read, nil|empty for reading all.
+	Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error)
+
+	// Update updates a record in the transaction.
+	// table: The name of the table.
+	// key: The record key of the record to update.
+	// values: A map of field/value pairs to update in the record.
+	Update(ctx context.Context, table string, key string, values map[string][]byte) error
+
+	// Insert inserts a record in the transaction.
+	// table: The name of the table.
+	// key: The record key of the record to insert.
+	// values: A map of field/value pairs to insert in the record.
+	Insert(ctx context.Context, table string, key string, values map[string][]byte) error
+
+	// Delete deletes a record in the transaction.
+	// table: The name of the table.
+	// key: The record key of the record to delete.
+	Delete(ctx context.Context, table string, key string) error
+
+	// Commit commits the transaction.
+	Commit(ctx context.Context) error
+
+	// Rollback aborts the transaction.
+	Rollback(ctx context.Context) error
+}
+
+// Unwrapper is the interface for the DB wrapping another one, like the
+// client wrapper measuring the operations.
+type Unwrapper interface {
+	// Unwrap returns the wrapped DB.
+	Unwrap() DB
+}
+
+// Supports reports whether db, or the DB it wraps, implements the interface
+// iface points to, e.g. Supports(db, (*TxnDB)(nil)). A wrapper implements
+// every optional interface and fails the operations its DB doesn't support.
+func Supports(db DB, iface interface{}) bool {
+	for {
+		u, ok := db.(Unwrapper)
+		if !ok {
+			break
+		}
+		db = u.Unwrap()
+	}
+	return reflect.TypeOf(db).Implements(reflect.TypeOf(iface).Elem())
+}
+
 var dbCreators = map[string]DBCreator{}
 
 // RegisterDBCreator registers a creator for the database
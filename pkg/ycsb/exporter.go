@@ -0,0 +1,63 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ycsb
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/magiconair/properties"
+)
+
+// OpResult is the measurement result of one operation.
+type OpResult struct {
+	// Op is the name of the operation, e.g. READ.
+	Op string
+	// Elapsed is the time since the operation was first measured.
+	Elapsed time.Duration
+	// Hist holds the latencies of the operation, in Unit.
+	Hist *hdrhistogram.Histogram
+	// Unit is the unit of the latencies recorded in Hist.
+	Unit time.Duration
+}
+
+// ExporterCreator creates an Exporter.
+type ExporterCreator interface {
+	Create(p *properties.Properties) (Exporter, error)
+}
+
+// Exporter writes the measurement results in a specific format.
+type Exporter interface {
+	// Export writes the results of all operations to the writer.
+	Export(w io.Writer, results []OpResult) error
+}
+
+var exporterCreators = map[string]ExporterCreator{}
+
+// RegisterExporterCreator registers a creator for the exporter
+func RegisterExporterCreator(name string, creator ExporterCreator) {
+	_, ok := exporterCreators[name]
+	if ok {
+		panic(fmt.Sprintf("duplicate register exporter %s", name))
+	}
+
+	exporterCreators[name] = creator
+}
+
+// GetExporterCreator gets the ExporterCreator for the exporter
+func GetExporterCreator(name string) ExporterCreator {
+	return exporterCreators[name]
+}
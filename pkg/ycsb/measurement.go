@@ -29,5 +29,6 @@ type Measurer interface {
 	// Output writes the measurement results to the specified writer.
 	Output(w io.Writer) error
 
-	ExportLatency(w io.Writer)
+	// Results returns the measurement results of all operations for exporting.
+	Results() []OpResult
 }
@@ -53,6 +53,68 @@ type Workload interface {
 	DoBatchTransaction(ctx context.Context, batchSize int, db DB) error
 }
 
+// DBCheckWorkload is the interface for the Workload that needs the DB to
+// implement optional interfaces like TxnDB.
+type DBCheckWorkload interface {
+	// CheckDB returns an error if the workload can't run on db. It is called
+	// once before the workers start.
+	CheckDB(db DB) error
+}
+
+// VerifyWorkload is the interface for the Workload that can check the records
+// in the database after a load or a run.
+type VerifyWorkload interface {
+	// Verify checks the records of the workload with one goroutine per DB,
+	// and reports the missing, extra and corrupted ones.
+	Verify(ctx context.Context, dbs []DB) (*VerifyReport, error)
+}
+
+// VerifyIssues counts the records with an issue and keeps some of their keys.
+type VerifyIssues struct {
+	Count   int64
+	Samples []string
+}
+
+// Add counts a record, keeping its key if there are less than samples keys.
+func (v *VerifyIssues) Add(key string, samples int) {
+	v.Count++
+	if len(v.Samples) < samples {
+		v.Samples = append(v.Samples, key)
+	}
+}
+
+// Merge adds the records of o.
+func (v *VerifyIssues) Merge(o VerifyIssues, samples int) {
+	v.Count += o.Count
+	for _, key := range o.Samples {
+		if len(v.Samples) >= samples {
+			break
+		}
+		v.Samples = append(v.Samples, key)
+	}
+}
+
+// VerifyReport is the result of VerifyWorkload.Verify.
+type VerifyReport struct {
+	// Checked is the number of the records expected in the database.
+	Checked   int64
+	Missing   VerifyIssues
+	Extra     VerifyIssues
+	Corrupted VerifyIssues
+	// Scanned is whether the records were scanned, only a scan finds the
+	// extra records.
+	Scanned bool
+}
+
+// Merge adds the records of o.
+func (r *VerifyReport) Merge(o *VerifyReport, samples int) {
+	r.Checked += o.Checked
+	r.Missing.Merge(o.Missing, samples)
+	r.Extra.Merge(o.Extra, samples)
+	r.Corrupted.Merge(o.Corrupted, samples)
+	r.Scanned = r.Scanned || o.Scanned
+}
+
 var workloadCreators = map[string]WorkloadCreator{}
 
 // RegisterWorkloadCreator registers a creator for the workload